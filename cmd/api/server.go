@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -8,11 +9,14 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/jorge-sader/go-rest-api/internal/api/handlers"
 	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
 	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
 	"github.com/jorge-sader/go-rest-api/internal/api/router"
+	"github.com/jorge-sader/go-rest-api/pkg/notifier"
 	"github.com/jorge-sader/go-rest-api/pkg/utils"
 
 	"golang.org/x/net/http2"
@@ -31,19 +35,60 @@ func main() {
 	key := "cmd/api/key.pem"
 
 	// Connect to database
-	_, err := sqlconnect.ConnectDB()
+	db, err := sqlconnect.ConnectDB()
 	if err != nil {
 		fmt.Println("error connecting to DB: ", err)
 		return
 	}
 
+	// Apply pending schema migrations
+	if err := sqlconnect.Migrate(context.Background(), db); err != nil {
+		log.Fatalln("Error applying migrations:", err)
+	}
+	db.Close()
+
+	// Load RBAC policy
+	policyFile := os.Getenv("RBAC_POLICY_FILE")
+	if policyFile == "" {
+		policyFile = "cmd/api/rbac_policy.json"
+	}
+	if err := middlewares.LoadPolicy(policyFile); err != nil {
+		log.Fatalln("Error loading RBAC policy:", err)
+	}
+
+	// Load OAuth2 signing keys (OAUTH_SIGNING_KEY_FILES, first key signs)
+	if err := utils.LoadSigningKeys(); err != nil {
+		log.Fatalln("Error loading OAuth signing keys:", err)
+	}
+
+	// Configure notifications (password reset tokens)
+	n, err := notifier.FromEnv()
+	if err != nil {
+		log.Fatalln("Error configuring notifier:", err)
+	}
+	handlers.SetNotifier(n)
+
+	// Purge soft-deleted rows past the retention window
+	if err := startPurgeScheduler(os.Getenv("SOFT_DELETE_RETENTION"), os.Getenv("SOFT_DELETE_PURGE_INTERVAL")); err != nil {
+		log.Fatalln("Error configuring soft delete purge:", err)
+	}
+
+	// Expire idempotency keys (IDEMPOTENCY_KEY_TTL, default 24h)
+	go expireIdempotencyKeys(time.Hour)
+
 	// Configure TLS
 	tlsConfig := &tls.Config{
 		MinVersion: tls.VersionTLS12,
-		// // Mutual TLS (mTLS) Config
-		// // mTLS is more secure but impractical as Client must have same certificate as Server)
-		// ClientAuth: tls.RequireAndVerifyClientCert, // enforce mTLS
-		// ClientCAs:  loadClientCAs(),
+	}
+
+	// Mutual TLS (mTLS)
+	// MTLS_MODE=off (default) never asks for client certificates.
+	// MTLS_MODE=optional verifies a client certificate when one is presented; routes wrapped in
+	// middlewares.RequireClientCert (integration endpoints) reject requests without one.
+	// MTLS_MODE=required rejects every connection without a valid client certificate, and callers
+	// with the integration role must be identified by theirs (middlewares.RequireClientCertForIntegrations).
+	if err := configureMTLS(tlsConfig, os.Getenv("MTLS_MODE")); err != nil {
+		log.Fatalln("Error configuring mutual TLS:", err)
 	}
 
 	// TODO: uncomment/reevaluate after routes are done
@@ -62,10 +107,13 @@ func main() {
 		// Innermost (runs last, ends first)
 		// middlewares.Hpp(hppOptions), // TODO: uncomment/reevaluate after routes are done
 		// middlewares.Compression,     // TODO: uncomment/reevaluate after routes are done
+		middlewares.Negotiate,
+		middlewares.Authenticate,
 		middlewares.SecurityHeaders,
 		// middlewares.ResponseTime, // TODO: uncomment/reevaluate after routes are done
 		// rl.Middleware,   // TODO: uncomment/reevaluate after routes are done
 		middlewares.Cors,
+		middlewares.RequestID,
 		// Outermost (runs first, ends last)
 	)
 
@@ -100,15 +148,117 @@ func main() {
 	// }
 }
 
-// loadClientCAs loads client certificates of authorization required for mutual TLS (mTLS)
-func loadClientCAs() *x509.CertPool {
+// configureMTLS sets the client certificate policy and CA pool on tlsConfig and loads the
+// certificate-to-identity mapping from MTLS_IDENTITIES_FILE.
+func configureMTLS(tlsConfig *tls.Config, mode string) error {
+	switch mode {
+	case "", "off":
+		return nil
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "required":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return fmt.Errorf("unknown MTLS_MODE %q (want off, optional or required)", mode)
+	}
+
+	caFile := os.Getenv("MTLS_CA_FILE")
+	if caFile == "" {
+		return fmt.Errorf("MTLS_CA_FILE is required when MTLS_MODE is %s", mode)
+	}
+	clientCAs, err := loadClientCAs(caFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig.ClientCAs = clientCAs
+
+	identitiesFile := os.Getenv("MTLS_IDENTITIES_FILE")
+	if identitiesFile == "" {
+		identitiesFile = "cmd/api/client_identities.json"
+	}
+	return middlewares.LoadClientIdentities(identitiesFile)
+}
+
+// loadClientCAs loads the CA bundle used to verify client certificates for mutual TLS (mTLS)
+func loadClientCAs(path string) (*x509.CertPool, error) {
 	clientCAs := x509.NewCertPool()
 
-	caCert, err := os.ReadFile("cert.pem")
+	caCert, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalln("Error loading client CA:", err)
+		return nil, fmt.Errorf("loading client CA bundle: %w", err)
+	}
+
+	if !clientCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in client CA bundle %s", path)
+	}
+	return clientCAs, nil
+}
+
+// startPurgeScheduler periodically hard-deletes rows soft deleted longer ago than retention
+// (default 720h, i.e. 30 days; "off" disables purging), checking every interval (default 24h).
+func startPurgeScheduler(retention, interval string) error {
+	if retention == "off" {
+		log.Println("Soft delete purge disabled")
+		return nil
+	}
+
+	retentionPeriod, every := 720*time.Hour, 24*time.Hour
+	var err error
+	if retention != "" {
+		if retentionPeriod, err = time.ParseDuration(retention); err != nil || retentionPeriod <= 0 {
+			return fmt.Errorf("invalid SOFT_DELETE_RETENTION %q", retention)
+		}
+	}
+	if interval != "" {
+		if every, err = time.ParseDuration(interval); err != nil || every <= 0 {
+			return fmt.Errorf("invalid SOFT_DELETE_PURGE_INTERVAL %q", interval)
+		}
 	}
 
-	clientCAs.AppendCertsFromPEM(caCert)
-	return clientCAs
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			purgeDeleted(time.Now().Add(-retentionPeriod))
+			<-ticker.C
+		}
+	}()
+	return nil
+}
+
+func purgeDeleted(cutoff time.Time) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Println("Error connecting to DB for purge:", err)
+		return
+	}
+	defer db.Close()
+
+	for _, table := range sqlconnect.SoftDeleteTables {
+		purged, err := sqlconnect.PurgeDeleted(context.Background(), db, table, cutoff)
+		if err != nil {
+			log.Printf("Error purging soft-deleted %s: %v", table, err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d %s deleted before %s", purged, table, cutoff.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// expireIdempotencyKeys deletes expired idempotency keys every interval.
+func expireIdempotencyKeys(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		db, err := sqlconnect.ConnectDB()
+		if err != nil {
+			log.Println("Error connecting to DB for idempotency key cleanup:", err)
+			continue
+		}
+		if deleted, err := sqlconnect.DeleteExpiredIdempotencyKeys(context.Background(), db); err == nil && deleted > 0 {
+			log.Printf("Deleted %d expired idempotency keys", deleted)
+		}
+		db.Close()
+	}
 }
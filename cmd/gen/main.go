@@ -0,0 +1,518 @@
+// Command gen generates the code of the CRUD resources from their definitions in
+// internal/models/defs, one YAML file per model:
+//
+//	internal/models/<name>_gen.go                      the model, its db tags and field maps
+//	internal/api/repositories/migrations/000_resources.sql  CREATE TABLE DDL of every resource
+//	internal/api/handlers/resources_gen.go             the handlers.Resource serving each table
+//	internal/api/router/resources_gen.go               registration of their routes
+//
+// It runs from anywhere in the module, usually through go generate:
+//
+//	go generate ./internal/models
+//	go run ./cmd/gen -check    # exit 1 when the generated files are stale
+//
+// The DDL creates the tables on a fresh database. As a migration it runs once, so it is never
+// changed once released: a column or table added later takes a numbered migration of its own,
+// named by the migration key of the field or definition, which leaves it out of the DDL. The
+// generator refuses to rewrite an existing 000_resources.sql, in either mode.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defsDir       = "internal/models/defs"
+	modelsDir     = "internal/models"
+	migrationsDir = "internal/api/repositories/migrations"
+	migrationFile = migrationsDir + "/000_resources.sql"
+	handlersFile  = "internal/api/handlers/resources_gen.go"
+	routerFile    = "internal/api/router/resources_gen.go"
+)
+
+// definition describes one resource.
+type definition struct {
+	// Model is the Go type, e.g. "Student"; it names single records in messages too.
+	Model string `yaml:"model"`
+	// Table is the table, the route prefix and the RBAC resource.
+	Table string `yaml:"table"`
+	// Doc and ResourceDoc are added to the doc comments of the model and of its resource.
+	Doc         string `yaml:"doc"`
+	ResourceDoc string `yaml:"resource_doc"`
+	// SoftDelete names the deleted_at column of soft-deletable models.
+	SoftDelete string `yaml:"soft_delete"`
+	// Version names the version column of versioned models.
+	Version string `yaml:"version"`
+	// Routes registers the generic routes in the router; defaults to true.
+	Routes *bool `yaml:"routes"`
+	// Migration names the migration creating the table of a resource added after 000_resources.sql.
+	Migration string  `yaml:"migration"`
+	Fields    []field `yaml:"fields"`
+
+	file string // YAML file name without extension
+}
+
+type field struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Column   string `yaml:"column"`
+	SQL      string `yaml:"sql"`
+	Validate string `yaml:"validate"`
+	// Sort and Filter list the field in SortableFields and FilterableFields.
+	Sort   bool `yaml:"sort"`
+	Filter bool `yaml:"filter"`
+	// Hidden fields are never serialized nor mapped by db tag; their column only appears in the DDL.
+	Hidden bool `yaml:"hidden"`
+	// References names the table a foreign key points to.
+	References string `yaml:"references"`
+	// Migration names the migration adding the column to a table created by an earlier one.
+	Migration string `yaml:"migration"`
+	Comment   string `yaml:"comment"`
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gen: ")
+	check := flag.Bool("check", false, "report stale generated files instead of writing them")
+	flag.Parse()
+
+	root, err := moduleRoot()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defs, err := loadDefinitions(filepath.Join(root, defsDir))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := checkMigrations(filepath.Join(root, migrationsDir), defs); err != nil {
+		log.Fatal(err)
+	}
+	files, err := generate(defs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Databases record 000_resources.sql as applied and never run it again
+	if current, err := os.ReadFile(filepath.Join(root, migrationFile)); err == nil && !bytes.Equal(current, files[migrationFile]) {
+		log.Fatalf("%s is released and must not change: add a numbered migration and name it in the migration key of the new fields or definitions", migrationFile)
+	}
+
+	// Generated models whose definition is gone are stale too
+	existing, err := filepath.Glob(filepath.Join(root, modelsDir, "*_gen.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var orphans []string
+	for _, path := range existing {
+		rel, _ := filepath.Rel(root, path)
+		if _, ok := files[filepath.ToSlash(rel)]; !ok {
+			orphans = append(orphans, rel)
+		}
+	}
+
+	if *check {
+		stale := orphans
+		for name, content := range files {
+			current, err := os.ReadFile(filepath.Join(root, name))
+			if err != nil || !bytes.Equal(current, content) {
+				stale = append(stale, name)
+			}
+		}
+		if len(stale) > 0 {
+			slices.Sort(stale)
+			log.Fatalf("generated files are stale, run go generate ./internal/models:\n\t%s", strings.Join(stale, "\n\t"))
+		}
+		return
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, name := range orphans {
+		if err := os.Remove(filepath.Join(root, name)); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// moduleRoot finds the directory holding go.mod, from the working directory up.
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// loadDefinitions reads and checks the definitions, ordered so that referenced tables come first.
+func loadDefinitions(dir string) ([]*definition, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no definitions in %s", dir)
+	}
+
+	byTable := make(map[string]*definition)
+	var defs []*definition
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		def := &definition{file: strings.TrimSuffix(filepath.Base(path), ".yaml")}
+		if err := decoder.Decode(def); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := def.check(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, ok := byTable[def.Table]; ok {
+			return nil, fmt.Errorf("%s: table %s is defined twice", path, def.Table)
+		}
+		byTable[def.Table] = def
+		defs = append(defs, def)
+	}
+
+	var ordered []*definition
+	visiting := make(map[string]bool)
+	var visit func(def *definition) error
+	visit = func(def *definition) error {
+		if slices.Contains(ordered, def) {
+			return nil
+		}
+		if visiting[def.Table] {
+			return fmt.Errorf("tables %s reference each other", def.Table)
+		}
+		visiting[def.Table] = true
+		for _, f := range def.Fields {
+			if f.References == "" || f.References == def.Table {
+				continue
+			}
+			referenced, ok := byTable[f.References]
+			if !ok {
+				return fmt.Errorf("%s.%s references undefined table %s", def.Table, f.Column, f.References)
+			}
+			if err := visit(referenced); err != nil {
+				return err
+			}
+		}
+		ordered = append(ordered, def)
+		return nil
+	}
+	for _, def := range defs {
+		if err := visit(def); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func (def *definition) check() error {
+	switch {
+	case def.Model == "":
+		return errors.New("model is required")
+	case def.Table == "":
+		return errors.New("table is required")
+	case len(def.Fields) == 0:
+		return errors.New("fields are required")
+	}
+
+	names := make(map[string]bool)
+	columns := make(map[string]field)
+	for _, f := range def.Fields {
+		if f.Name == "" || f.Type == "" || f.Column == "" || f.SQL == "" {
+			return fmt.Errorf("field %q needs a name, type, column and sql", f.Name)
+		}
+		if names[f.Name] || columns[f.Column].Name != "" {
+			return fmt.Errorf("field %s or column %s is defined twice", f.Name, f.Column)
+		}
+		if f.Hidden && (f.Sort || f.Filter || f.Validate != "") {
+			return fmt.Errorf("hidden field %s cannot be sorted, filtered or validated", f.Name)
+		}
+		names[f.Name] = true
+		columns[f.Column] = f
+	}
+
+	if id := columns["id"]; id.Type != "int" || id.Hidden || id.Migration != "" {
+		return errors.New(`the primary key must be a visible int column named "id", created with the table`)
+	}
+	if def.SoftDelete != "" {
+		if f := columns[def.SoftDelete]; f.Type != "*time.Time" || f.Hidden {
+			return fmt.Errorf("soft_delete column %s must be a visible *time.Time field", def.SoftDelete)
+		}
+	}
+	if def.Version != "" {
+		if f := columns[def.Version]; f.Type != "int" || f.Hidden {
+			return fmt.Errorf("version column %s must be a visible int field", def.Version)
+		}
+	}
+	return nil
+}
+
+// checkMigrations checks that the migrations named by the definitions exist, run after
+// 000_resources.sql and mention the table or column they create.
+func checkMigrations(dir string, defs []*definition) error {
+	check := func(name, subject string) error {
+		if name <= filepath.Base(migrationFile) {
+			return fmt.Errorf("%s: migration %s must run after %s", subject, name, filepath.Base(migrationFile))
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", subject, err)
+		}
+		if !strings.Contains(string(data), subject[strings.LastIndex(subject, ".")+1:]) {
+			return fmt.Errorf("%s: migration %s does not mention it", subject, name)
+		}
+		return nil
+	}
+	for _, def := range defs {
+		if def.Migration != "" {
+			if err := check(def.Migration, def.Table); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, f := range def.Fields {
+			if f.Migration != "" {
+				if err := check(f.Migration, def.Table+"."+f.Column); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Tag returns the struct tag of the field.
+func (f field) Tag() string {
+	if f.Hidden {
+		return `json:"-"`
+	}
+	tag := fmt.Sprintf(`json:"%s,omitempty" db:"%s"`, f.Column, f.Column)
+	if f.Validate != "" {
+		tag += fmt.Sprintf(` validate:"%s"`, f.Validate)
+	}
+	return tag
+}
+
+// File is the name of the definition file, without extension.
+func (def *definition) File() string {
+	return def.file
+}
+
+// VersionField returns the Go name of the version field.
+func (def *definition) VersionField() string {
+	for _, f := range def.Fields {
+		if f.Column == def.Version {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// Var is the name of the handlers.Resource variable, e.g. "Students" for students.
+func (def *definition) Var() string {
+	var b strings.Builder
+	for word := range strings.SplitSeq(def.Table, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func (def *definition) UsesTime() bool {
+	return slices.ContainsFunc(def.Fields, func(f field) bool { return strings.Contains(f.Type, "time.") })
+}
+
+func (def *definition) Sortable() []field {
+	return slices.DeleteFunc(slices.Clone(def.Fields), func(f field) bool { return !f.Sort })
+}
+
+func (def *definition) Filterable() []field {
+	return slices.DeleteFunc(slices.Clone(def.Fields), func(f field) bool { return !f.Filter })
+}
+
+func (def *definition) Registered() bool {
+	return def.Routes == nil || *def.Routes
+}
+
+// generate returns the content of every generated file, by path from the module root.
+func generate(defs []*definition) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, def := range defs {
+		content, err := render(modelTemplate, def, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", def.file, err)
+		}
+		files[modelsDir+"/"+def.file+"_gen.go"] = content
+	}
+
+	outputs := []struct {
+		name  string
+		tmpl  *template.Template
+		gofmt bool
+	}{
+		{migrationFile, migrationTemplate, false},
+		{handlersFile, handlersTemplate, true},
+		{routerFile, routerTemplate, true},
+	}
+	for _, out := range outputs {
+		content, err := render(out.tmpl, defs, out.gofmt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", out.name, err)
+		}
+		files[out.name] = content
+	}
+	return files, nil
+}
+
+func render(tmpl *template.Template, data any, gofmt bool) ([]byte, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	if !gofmt {
+		return b.Bytes(), nil
+	}
+	return format.Source(b.Bytes())
+}
+
+var funcs = template.FuncMap{
+	// comment turns text into // comment lines.
+	"comment": func(text string) string {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return ""
+		}
+		return "// " + strings.ReplaceAll(text, "\n", "\n// ") + "\n"
+	},
+	"last": func(i int, list any) bool {
+		switch list := list.(type) {
+		case []string:
+			return i == len(list)-1
+		}
+		return false
+	},
+	// columns returns the column definitions and constraints of a CREATE TABLE, leaving out the
+	// columns added by later migrations.
+	"columns": func(def *definition) []string {
+		var lines []string
+		for _, f := range def.Fields {
+			if f.Migration == "" {
+				lines = append(lines, f.Column+" "+f.SQL)
+			}
+		}
+		for _, f := range def.Fields {
+			if f.References != "" && f.Migration == "" {
+				lines = append(lines, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (id)", f.Column, f.References))
+			}
+		}
+		return lines
+	},
+}
+
+var modelTemplate = template.Must(template.New("model").Funcs(funcs).Parse(`// Code generated by cmd/gen from defs/{{.File}}.yaml. DO NOT EDIT.
+
+package models
+{{if .UsesTime}}
+import "time"
+{{end}}
+// {{.Model}} is a record of the {{.Table}} table.
+{{comment .Doc}}type {{.Model}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+
+func ({{.Model}}) SortableFields() map[string]string {
+	return map[string]string{
+{{- range .Sortable}}
+		"{{.Column}}": "{{.Column}}",
+{{- end}}
+	}
+}
+
+func ({{.Model}}) FilterableFields() map[string]string {
+	return map[string]string{
+{{- range .Filterable}}
+		"{{.Column}}": "{{.Column}}",
+{{- end}}
+	}
+}
+{{- if .SoftDelete}}
+
+func ({{.Model}}) DeletedAtColumn() string {
+	return "{{.SoftDelete}}"
+}
+{{- end}}
+{{- if .Version}}
+
+func (m {{.Model}}) RecordVersion() int {
+	return m.{{.VersionField}}
+}
+{{- end}}
+`))
+
+var migrationTemplate = template.Must(template.New("migration").Funcs(funcs).Parse(`-- Code generated by cmd/gen from internal/models/defs. DO NOT EDIT.
+{{range .}}{{if not .Migration}}
+CREATE TABLE IF NOT EXISTS {{.Table}} (
+{{- $columns := columns .}}
+{{- range $i, $line := $columns}}
+    {{$line}}{{if not (last $i $columns)}},{{end}}
+{{- end}}
+);
+{{end}}{{end -}}
+`))
+
+var handlersTemplate = template.Must(template.New("handlers").Funcs(funcs).Parse(`// Code generated by cmd/gen from internal/models/defs. DO NOT EDIT.
+
+package handlers
+
+import "github.com/jorge-sader/go-rest-api/internal/models"
+{{range .}}
+// {{.Var}} serves the {{.Table}} table.
+{{comment .ResourceDoc}}var {{.Var}} = &Resource[models.{{.Model}}]{Table: "{{.Table}}", Singular: "{{.Model}}"}
+{{end -}}
+`))
+
+var routerTemplate = template.Must(template.New("router").Funcs(funcs).Parse(`// Code generated by cmd/gen from internal/models/defs. DO NOT EDIT.
+
+package router
+
+import "github.com/jorge-sader/go-rest-api/internal/api/handlers"
+
+// registerResources adds the generic routes of the generated resources to api.
+func registerResources(api *routes) {
+{{- range .}}{{if .Registered}}
+	register(api, handlers.{{.Var}})
+{{- end}}{{end}}
+}
+`))
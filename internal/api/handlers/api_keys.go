@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// createAPIKeyRequest is the payload accepted by CreateAPIKeyHandler.
+// Scopes use the RBAC permission format "<resource>:<action>", e.g. "students:read" or "teachers:*".
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// validateScopes checks every scope is a "<resource>:<action>" pair.
+func validateScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		resource, action, ok := strings.Cut(scope, ":")
+		if !ok || resource == "" || action == "" || strings.ContainsAny(scope, " \t\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateAPIKeyHandler creates a scoped API key. The full key is only returned in this response.
+func CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		responder.Error(w, r, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if !validateScopes(req.Scopes) {
+		responder.Error(w, r, `At least one scope of the form "<resource>:<action>" is required`, http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		responder.Error(w, r, "expires_at must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	key, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		log.Printf("Error generating API key: %v", err)
+		responder.Error(w, r, "Error generating API key", http.StatusInternalServerError)
+		return
+	}
+
+	createdBy := "unknown"
+	if principal, ok := utils.PrincipalFromContext(r.Context()); ok {
+		createdBy = principal.Username
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	apiKey := models.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	apiKey.ID, err = sqlconnect.CreateAPIKey(r.Context(), db, apiKey)
+	if err != nil {
+		responder.Error(w, r, "Error creating API key", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string        `json:"status"`
+		Key    string        `json:"key"`
+		Data   models.APIKey `json:"data"`
+	}{
+		Status: "success",
+		Key:    key,
+		Data:   apiKey,
+	}
+	writeResponse(w, r, http.StatusCreated, response)
+}
+
+// ListAPIKeysHandler lists API keys with their prefixes, scopes and usage. Secrets are never returned.
+func ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	keys, err := sqlconnect.ListAPIKeys(r.Context(), db)
+	if err != nil {
+		log.Printf("Error querying API keys: %v", err)
+		responder.Error(w, r, "Error querying API keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string          `json:"status"`
+		Count  int             `json:"count"`
+		Data   []models.APIKey `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(keys),
+		Data:   keys,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// RevokeAPIKeyHandler revokes an API key by ID. Revoked keys are kept for auditing.
+func RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 {
+		responder.Error(w, r, "Invalid API key id", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	rowsAffected, err := sqlconnect.RevokeAPIKey(r.Context(), db, id)
+	if err != nil {
+		responder.Error(w, r, "Error revoking API key", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		responder.Error(w, r, "API key not found or already revoked", http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		ID     int    `json:"id"`
+	}{
+		Status: "success",
+		ID:     id,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
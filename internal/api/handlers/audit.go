@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// Audit actions recorded for data mutations.
+const (
+	auditCreate = "create"
+	auditUpdate = "update"
+	auditDelete = "delete"
+)
+
+// fieldChange is the before/after value of one field in an audit entry.
+// Old is omitted for creations and New for deletions; otherwise both are present, null for NULL.
+type fieldChange struct {
+	Old *any `json:"old,omitempty"`
+	New *any `json:"new,omitempty"`
+}
+
+// auditActor names the caller responsible for a request in the audit log.
+func auditActor(r *http.Request) string {
+	if principal, ok := utils.PrincipalFromContext(r.Context()); ok {
+		return principal.Username
+	}
+	return "anonymous"
+}
+
+// recordMutation writes an audit entry for a change to one record inside the caller's transaction,
+// so the change and its trace commit or roll back together. Pass nil as before for creations
+// and as after for deletions; only fields whose value differs are recorded.
+func recordMutation(r *http.Request, tx *sql.Tx, action, resource string, id int, before, after any) error {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		log.Printf("Error computing audit diff for %s/%d: %v", resource, id, err)
+		return err
+	}
+
+	return sqlconnect.InsertAuditEntry(r.Context(), tx, models.AuditEntry{
+		Actor:     auditActor(r),
+		Action:    action,
+		Resource:  resource,
+		RecordID:  strconv.Itoa(id),
+		Changes:   changes,
+		RequestID: utils.RequestIDFromContext(r.Context()),
+	})
+}
+
+// diffFields compares the column values of two records, so that empty, zero and NULL values the
+// JSON of the models leaves out are recorded too. A nil record has no fields.
+func diffFields(before, after any) (json.RawMessage, error) {
+	oldFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]fieldChange)
+	for name, oldValue := range oldFields {
+		newValue, ok := newFields[name]
+		switch {
+		case !ok:
+			diff[name] = fieldChange{Old: &oldValue}
+		case !reflect.DeepEqual(oldValue, newValue):
+			diff[name] = fieldChange{Old: &oldValue, New: &newValue}
+		}
+	}
+	for name, newValue := range newFields {
+		if _, seen := oldFields[name]; !seen {
+			diff[name] = fieldChange{New: &newValue}
+		}
+	}
+	if len(diff) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(diff)
+}
+
+func auditFields(record any) (map[string]any, error) {
+	if record == nil {
+		return map[string]any{}, nil
+	}
+	return recordFields(record)
+}
+
+// GetAuditLogHandler lists audit entries, newest first.
+// Query parameters: actor, action, resource, record_id, from and to (RFC 3339, to is exclusive),
+// limit (default 100, max 1000) and offset.
+func GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := sqlconnect.AuditFilter{
+		Actor:    query.Get("actor"),
+		Action:   query.Get("action"),
+		Resource: query.Get("resource"),
+		RecordID: query.Get("record_id"),
+		Limit:    100,
+	}
+
+	for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				responder.Error(w, r, "Invalid "+param+": expected an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			*target = t
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		responder.Error(w, r, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 1000 {
+			responder.Error(w, r, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			responder.Error(w, r, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	entries, err := sqlconnect.ListAuditEntries(r.Context(), db, filter)
+	if err != nil {
+		responder.Error(w, r, "Error querying audit log", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string              `json:"status"`
+		Count  int                 `json:"count"`
+		Data   []models.AuditEntry `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(entries),
+		Data:   entries,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
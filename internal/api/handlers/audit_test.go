@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+func TestDiffFields(t *testing.T) {
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	classroom := models.Classroom{ID: 4, RoomNumber: "101", Building: "North", Capacity: 30, Version: 1}
+	tests := []struct {
+		name          string
+		before, after any
+		want          string // empty when nothing changed
+	}{
+		{
+			name:  "creation records every column",
+			after: models.Classroom{ID: 4, RoomNumber: "101", Version: 1},
+			want: `{"id": {"new": 4}, "room_number": {"new": "101"}, "building": {"new": ""},
+				"capacity": {"new": 0}, "version": {"new": 1}}`,
+		},
+		{
+			name:   "deletion",
+			before: &classroom,
+			want: `{"id": {"old": 4}, "room_number": {"old": "101"}, "building": {"old": "North"},
+				"capacity": {"old": 30}, "version": {"old": 1}}`,
+		},
+		{
+			name:   "update to empty and zero values",
+			before: classroom,
+			after:  models.Classroom{ID: 4, RoomNumber: "101", Version: 2},
+			want:   `{"building": {"old": "North", "new": ""}, "capacity": {"old": 30, "new": 0}, "version": {"old": 1, "new": 2}}`,
+		},
+		{
+			name:   "update from empty values",
+			before: models.Classroom{ID: 4, RoomNumber: "101"},
+			after:  models.Classroom{ID: 4, RoomNumber: "101", Building: "South"},
+			want:   `{"building": {"old": "", "new": "South"}}`,
+		},
+		{
+			name:   "update to NULL",
+			before: models.Student{ID: 2, FirstName: "Ada", DeletedAt: &deletedAt},
+			after:  models.Student{ID: 2, FirstName: "Ada"},
+			want:   `{"deleted_at": {"old": "2025-03-01T12:00:00Z", "new": null}}`,
+		},
+		{
+			name:   "update from NULL",
+			before: models.Student{ID: 2, FirstName: "Ada"},
+			after:  models.Student{ID: 2, FirstName: "Ada", DeletedAt: &deletedAt},
+			want:   `{"deleted_at": {"old": null, "new": "2025-03-01T12:00:00Z"}}`,
+		},
+		{
+			name:   "no change",
+			before: classroom,
+			after:  &classroom,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := diffFields(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if data != nil {
+					t.Errorf("diffFields() = %s, want no changes", data)
+				}
+				return
+			}
+			var got, want any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("diffFields() = %s, not JSON: %v", data, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("diffFields() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// loginRequest is the payload accepted by LoginHandler.
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginHandler authenticates an executive by username and password and issues an access token
+// together with a refresh token that starts a new session.
+// Legacy plaintext passwords and hashes created with outdated parameters are transparently
+// rehashed with the current argon2id settings after a successful login.
+//
+// Failed attempts are tracked per username (see lockout.go). Unknown usernames are verified
+// against a dummy hash and locked out like real ones, so neither the response nor its timing
+// reveals whether an account exists.
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		responder.Error(w, r, "Username and password are required", http.StatusBadRequest)
+		return
+	}
+	username := normalizeUsername(req.Username)
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	if rejectIfLocked(w, r, db, username) {
+		return
+	}
+
+	exec, err := sqlconnect.GetExecutiveByUsername(r.Context(), db, username)
+	if err != nil && err != sql.ErrNoRows {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+
+	storedHash := exec.Password
+	if err == sql.ErrNoRows {
+		storedHash = dummyPasswordHash()
+	}
+
+	ok, verifyErr := utils.VerifyPassword(req.Password, storedHash)
+	if verifyErr != nil {
+		log.Printf("Error verifying password for %s: %v", username, verifyErr)
+	}
+	if err == sql.ErrNoRows || !ok {
+		registerLoginFailure(r, db, username)
+		responder.Error(w, r, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := sqlconnect.ClearLoginFailures(r.Context(), db, username); err != nil {
+		log.Printf("Could not reset login failures for %s", username)
+	}
+
+	// Migrate legacy plaintext or outdated hashes now that we know the password
+	if utils.PasswordNeedsRehash(exec.Password) {
+		hash, err := utils.HashPassword(req.Password)
+		if err != nil {
+			log.Printf("Error rehashing password for executive %d: %v", exec.ID, err)
+		} else if err := sqlconnect.UpdateExecutivePassword(r.Context(), db, exec.ID, hash); err == nil {
+			log.Printf("Rehashed password for executive %d", exec.ID)
+		}
+	}
+
+	// Executives enrolled in 2FA must complete the second step at POST /auth/2fa/verify
+	if exec.TOTPEnabled {
+		writeMFAChallenge(w, r, exec)
+		return
+	}
+
+	startSession(w, r, db, exec)
+}
+
+// startSession starts a new refresh token family for a completed login and writes the token pair.
+func startSession(w http.ResponseWriter, r *http.Request, db *sql.DB, exec models.Executive) {
+	familyID, err := utils.RandomHex(16)
+	if err != nil {
+		log.Printf("Error generating token family: %v", err)
+		responder.Error(w, r, "Error generating refresh token", http.StatusInternalServerError)
+		return
+	}
+	refreshToken, refreshHash, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		log.Printf("Error generating refresh token: %v", err)
+		responder.Error(w, r, "Error generating refresh token", http.StatusInternalServerError)
+		return
+	}
+	err = sqlconnect.CreateRefreshToken(r.Context(), db, exec.ID, familyID, refreshHash, time.Now().Add(refreshTokenTTL()))
+	if err != nil {
+		responder.Error(w, r, "Error storing refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	writeSession(w, r, exec, familyID, refreshToken)
+}
+
+// executiveFromContext returns the authenticated executive, writing 401/403 and returning false
+// when the caller is anonymous or a machine principal such as an API key.
+func executiveFromContext(w http.ResponseWriter, r *http.Request) (utils.Principal, bool) {
+	principal, ok := utils.PrincipalFromContext(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		responder.Error(w, r, "Authentication required", http.StatusUnauthorized)
+		return utils.Principal{}, false
+	}
+	if principal.Kind != utils.PrincipalExecutive {
+		responder.Error(w, r, "Only executives can use this endpoint", http.StatusForbidden)
+		return utils.Principal{}, false
+	}
+	return principal, true
+}
+
+// principalFor builds the token subject for exec in the session familyID, including the
+// restrictions its account is under.
+func principalFor(exec models.Executive, familyID string) utils.Principal {
+	return utils.Principal{
+		Kind:               utils.PrincipalExecutive,
+		ID:                 exec.ID,
+		Username:           exec.Username,
+		Role:               exec.Role,
+		MustChangePassword: exec.MustChangePassword,
+		MFAEnrollRequired:  !exec.TOTPEnabled && middlewares.RoleRequiresMFA(exec.Role),
+		Session:            familyID,
+	}
+}
+
+// sessionResponse is returned whenever a new access/refresh token pair is issued.
+type sessionResponse struct {
+	Status       string `json:"status"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenTTL reads REFRESH_TOKEN_EXPIRES_IN (e.g. "168h"), defaulting to 7 days.
+func refreshTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_EXPIRES_IN"))
+	if err != nil || ttl <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
+// writeSession signs an access token for exec and writes it with the already stored refresh token
+// of the token family familyID.
+func writeSession(w http.ResponseWriter, r *http.Request, exec models.Executive, familyID, refreshToken string) {
+	token, err := utils.SignToken(principalFor(exec, familyID))
+	if err != nil {
+		log.Printf("Error signing token: %v", err)
+		responder.Error(w, r, "Error signing token", http.StatusInternalServerError)
+		return
+	}
+
+	response := sessionResponse{
+		Status:       "success",
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// refreshRequest is the payload accepted by RefreshHandler and LogoutHandler.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshHandler exchanges a valid refresh token for a new access token and a rotated refresh token.
+// Presenting a refresh token that was already rotated or revoked is treated as token theft:
+// the whole token family is revoked and the caller must log in again.
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	current, err := sqlconnect.GetRefreshTokenByHash(r.Context(), db, utils.HashToken(req.RefreshToken))
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		log.Printf("Error querying refresh token: %v", err)
+		responder.Error(w, r, "Error querying refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	if current.RevokedAt != nil {
+		revokeReusedFamily(r, db, current)
+		responder.Error(w, r, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if time.Now().After(current.ExpiresAt) {
+		responder.Error(w, r, "Refresh token expired", http.StatusUnauthorized)
+		return
+	}
+
+	exec, err := sqlconnect.GetExecutiveByID(r.Context(), db, current.ExecutiveID)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+
+	refreshToken, refreshHash, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		log.Printf("Error generating refresh token: %v", err)
+		responder.Error(w, r, "Error generating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	err = sqlconnect.RotateRefreshToken(r.Context(), db, current, refreshHash, time.Now().Add(refreshTokenTTL()))
+	if err == sqlconnect.ErrRefreshTokenReused {
+		// Lost a race with another request presenting the same token
+		revokeReusedFamily(r, db, current)
+		responder.Error(w, r, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		responder.Error(w, r, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	writeSession(w, r, exec, current.FamilyID, refreshToken)
+}
+
+// revokeReusedFamily invalidates every token in the family of a replayed refresh token.
+func revokeReusedFamily(r *http.Request, db *sql.DB, replayed models.RefreshToken) {
+	n, err := sqlconnect.RevokeRefreshTokenFamily(r.Context(), db, replayed.FamilyID)
+	if err == nil {
+		log.Printf("Refresh token reuse detected for executive %d: revoked %d token(s) in family %s", replayed.ExecutiveID, n, replayed.FamilyID)
+	}
+}
+
+// LogoutHandler revokes the session (token family) the given refresh token belongs to. Access tokens
+// issued for the session are rejected from then on (see middlewares.Authorize).
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	current, err := sqlconnect.GetRefreshTokenByHash(r.Context(), db, utils.HashToken(req.RefreshToken))
+	if err == nil {
+		if _, err := sqlconnect.RevokeRefreshTokenFamily(r.Context(), db, current.FamilyID); err != nil {
+			responder.Error(w, r, "Error revoking session", http.StatusInternalServerError)
+			return
+		}
+	} else if err != sql.ErrNoRows {
+		log.Printf("Error querying refresh token: %v", err)
+		responder.Error(w, r, "Error querying refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	// Logging out with an unknown or already revoked token is not an error
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeExecutiveSessionsHandler revokes every active session of the executive in the path, refresh
+// and access tokens alike.
+func RevokeExecutiveSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 {
+		responder.Error(w, r, "Invalid executive id", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	revoked, err := sqlconnect.RevokeExecutiveRefreshTokens(r.Context(), db, id, "")
+	if err != nil {
+		responder.Error(w, r, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status       string `json:"status"`
+		ID           int    `json:"id"`
+		CountRevoked int64  `json:"count_revoked"`
+	}{
+		Status:       "success",
+		ID:           id,
+		CountRevoked: revoked,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
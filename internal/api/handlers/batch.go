@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// Batch POST and PATCH requests are atomic by default: one failing item rolls back the whole batch.
+// With ?mode=partial every item runs inside its own savepoint of the request transaction instead;
+// items that fail are rolled back individually, the rest are committed, and the response is a
+// 207 Multi-Status listing one result per item:
+//
+//	{"status": "partial", "count": 2, "succeeded": 1, "failed": 1, "results": [
+//	  {"index": 0, "status": 201, "id": 12, "data": {...}},
+//	  {"index": 1, "status": 422, "error": {"type": "/problems/validation-failed", ...}}
+//	]}
+
+// batchMode reads ?mode= of a batch request: "atomic" (the default) or "partial".
+// Any other mode gets a 400 and ok is false.
+func batchMode(w http.ResponseWriter, r *http.Request) (partial bool, ok bool) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "atomic":
+		return false, true
+	case "partial":
+		return true, true
+	default:
+		responder.Error(w, r, `Invalid mode "`+mode+`": use atomic or partial`, http.StatusBadRequest)
+		return false, false
+	}
+}
+
+// batchResult is the outcome of one item of a partial batch.
+type batchResult struct {
+	Index  int                `json:"index"`
+	Status int                `json:"status"`
+	ID     int                `json:"id,omitempty"`
+	Data   any                `json:"data,omitempty"`
+	Error  *responder.Problem `json:"error,omitempty"`
+}
+
+// batchResponse is the 207 Multi-Status body of a partial batch.
+type batchResponse struct {
+	Status    string        `json:"status"`
+	Count     int           `json:"count"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []batchResult `json:"results"`
+}
+
+// itemError is an error of a single batch item together with the status to report it with.
+type itemError struct {
+	status int
+	detail string
+}
+
+func (e *itemError) Error() string {
+	return e.detail
+}
+
+// failedItem builds the result of an item that failed with err.
+func failedItem(index int, err error) batchResult {
+	problem := responder.NewProblem(http.StatusInternalServerError, err.Error())
+	switch err := err.(type) {
+	case validator.Errors:
+		problem = responder.ValidationProblem(err)
+	case *itemError:
+		problem = responder.NewProblem(err.status, err.detail)
+	}
+	return batchResult{Index: index, Status: problem.Status, Error: &problem}
+}
+
+// writeItemError answers an atomic batch with the error of the item that failed.
+func writeItemError(w http.ResponseWriter, r *http.Request, err error) {
+	var itemErr *itemError
+	if errors.As(err, &itemErr) {
+		responder.Error(w, r, itemErr.detail, itemErr.status)
+		return
+	}
+	responder.Error(w, r, err.Error(), http.StatusInternalServerError)
+}
+
+// validateItem checks one item of a partial batch, returning its validator.Errors when it is invalid.
+func validateItem(r *http.Request, v validator.Validator, item any) error {
+	errs, err := v.Struct(r.Context(), item)
+	if err != nil {
+		log.Printf("Error validating payload: %v", err)
+		return &itemError{http.StatusInternalServerError, "Error validating payload"}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// withSavepoint runs fn inside a savepoint of tx, rolling back to the savepoint when fn reports
+// that it failed. The returned error is only set when the savepoint itself could not be managed.
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() (failed bool)) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_item`); err != nil {
+		log.Printf("Error creating savepoint: %v", err)
+		return err
+	}
+	release := `RELEASE SAVEPOINT batch_item`
+	if fn() {
+		release = `ROLLBACK TO SAVEPOINT batch_item`
+	}
+	// A deadlock rolls back the whole transaction, taking the savepoint with it
+	if _, err := tx.ExecContext(ctx, release); err != nil {
+		log.Printf("Error releasing savepoint: %v", err)
+		return err
+	}
+	return nil
+}
+
+// runPartialBatch processes n items inside tx, each within its own savepoint, commits and answers
+// 207 Multi-Status. item processes the item at index i; a result with an Error is rolled back.
+func runPartialBatch(w http.ResponseWriter, r *http.Request, tx *sql.Tx, n int, item func(i int) batchResult) {
+	results := make([]batchResult, 0, n)
+	failed := 0
+	for i := range n {
+		var result batchResult
+		err := withSavepoint(r.Context(), tx, func() bool {
+			result = item(i)
+			return result.Error != nil
+		})
+		if err != nil {
+			responder.Error(w, r, "Error processing batch", http.StatusInternalServerError)
+			return
+		}
+		if result.Error != nil {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	status := "partial"
+	switch failed {
+	case 0:
+		status = "success"
+	case n:
+		status = "error"
+	}
+
+	response := batchResponse{
+		Status:    status,
+		Count:     n,
+		Succeeded: n - failed,
+		Failed:    failed,
+		Results:   results,
+	}
+	writeResponse(w, r, http.StatusMultiStatus, response)
+}
@@ -0,0 +1,319 @@
+// Interactive documentation of the API, rendered from /openapi.json. Every operation can be tried
+// out: parameters and bodies are filled in from the document, and requests carry the credentials
+// entered in the header, kept in sessionStorage only.
+"use strict";
+
+const methods = ["get", "post", "put", "patch", "delete"];
+
+let spec;
+
+// el creates an element with attributes and children; strings become text nodes.
+function el(tag, attributes = {}, ...children) {
+	const node = document.createElement(tag);
+	for (const [name, value] of Object.entries(attributes)) {
+		if (value === false || value === undefined) {
+			continue;
+		}
+		if (name.startsWith("on")) {
+			node.addEventListener(name.slice(2), value);
+		} else {
+			node.setAttribute(name, value === true ? "" : value);
+		}
+	}
+	node.append(...children.flat().filter((child) => child !== null && child !== undefined));
+	return node;
+}
+
+// resolve follows a local $ref.
+function resolve(schema) {
+	while (schema && schema.$ref) {
+		schema = schema.$ref.replace(/^#\//, "").split("/").reduce((node, key) => node[key], spec);
+	}
+	return schema || {};
+}
+
+function typeOf(schema) {
+	return [].concat(schema.type || []).find((type) => type !== "null");
+}
+
+// example builds a sample value of schema. Read-only properties are left out of request bodies.
+function example(schema, request, depth = 0) {
+	schema = resolve(schema);
+	if (depth > 6) {
+		return null;
+	}
+	if (schema.anyOf) {
+		return example(schema.anyOf[0], request, depth + 1);
+	}
+	if (schema.enum) {
+		return schema.enum[0];
+	}
+	switch (typeOf(schema)) {
+	case "object": {
+		const value = {};
+		for (const [name, property] of Object.entries(schema.properties || {})) {
+			if (!(request && resolve(property).readOnly)) {
+				value[name] = example(property, request, depth + 1);
+			}
+		}
+		return value;
+	}
+	case "array":
+		return [example(schema.items, request, depth + 1)];
+	case "integer":
+	case "number":
+		return schema.minimum ?? 0;
+	case "boolean":
+		return false;
+	case "string":
+		return { "date-time": new Date().toISOString(), email: "user@example.com" }[schema.format] ?? "";
+	}
+	return null;
+}
+
+// describe lists the fields of a schema with their type and constraints.
+function describe(schema) {
+	schema = resolve(schema);
+	const rows = Object.entries(schema.properties || {}).map(([name, property]) => {
+		const resolved = resolve(property);
+		const notes = [];
+		if (resolved.readOnly) notes.push("read-only");
+		if (resolved.format) notes.push(resolved.format);
+		if (resolved.enum) notes.push("one of " + resolved.enum.join(", "));
+		if (resolved.minimum !== undefined) notes.push("min " + resolved.minimum);
+		if (resolved.maximum !== undefined) notes.push("max " + resolved.maximum);
+		if (resolved.minLength !== undefined) notes.push("min length " + resolved.minLength);
+		if (resolved.maxLength !== undefined) notes.push("max length " + resolved.maxLength);
+		if (resolved.description) notes.push(resolved.description);
+		const type = property.$ref ? property.$ref.split("/").pop() : [].concat(resolved.type || "any").join(" | ");
+		return el("tr", {},
+			el("td", { class: (schema.required || []).includes(name) ? "required" : false }, name),
+			el("td", {}, typeOf(resolved) === "array" && resolved.items.$ref ? resolved.items.$ref.split("/").pop() + "[]" : type),
+			el("td", {}, notes.join("; ")));
+	});
+	if (rows.length === 0) {
+		return el("pre", {}, JSON.stringify(example(schema, false), null, 2));
+	}
+	return el("table", {}, el("tr", {}, el("th", {}, "Field"), el("th", {}, "Type"), el("th", {}, "Notes")), rows);
+}
+
+function credentials() {
+	return document.getElementById("credentials").elements;
+}
+
+function parameterInput(parameter) {
+	const schema = resolve(parameter.schema);
+	const values = typeOf(schema) === "array" ? resolve(schema.items) : schema;
+	if (values.enum || typeOf(values) === "boolean") {
+		const options = values.enum || [true, false];
+		return el("select", { name: parameter.name, multiple: typeOf(schema) === "array" },
+			typeOf(schema) === "array" ? null : el("option", { value: "" }, ""),
+			options.map((option) => el("option", { value: String(option) }, String(option))));
+	}
+	return el("input", {
+		name: parameter.name,
+		required: parameter.required,
+		placeholder: typeOf(schema) === "array" ? "comma-separated" : typeOf(values) || "",
+	});
+}
+
+// send builds the request of an operation from its form and shows the response.
+async function send(method, path, operation, form, output) {
+	const headers = new Headers();
+	const query = new URLSearchParams();
+	for (const parameter of operation.parameters || []) {
+		const input = form.elements[parameter.name];
+		let values = [input.value.trim()];
+		if (input.multiple) {
+			values = Array.from(input.selectedOptions, (option) => option.value);
+		} else if (typeOf(resolve(parameter.schema)) === "array") {
+			values = input.value.split(",").map((value) => value.trim());
+		}
+		for (const value of values.filter((value) => value !== "")) {
+			switch (parameter.in) {
+			case "path":
+				path = path.replace("{" + parameter.name + "}", encodeURIComponent(value));
+				break;
+			case "query":
+				query.append(parameter.name, value);
+				break;
+			case "header":
+				headers.set(parameter.name, value);
+				break;
+			}
+		}
+	}
+
+	const fields = credentials();
+	if (!(operation.security && operation.security.length === 0)) {
+		if (fields.bearer.value) {
+			headers.set("Authorization", "Bearer " + fields.bearer.value);
+		} else if (fields.apiKey.value) {
+			headers.set("X-API-Key", fields.apiKey.value);
+		}
+	}
+	headers.set("Accept", fields.format.value + ", application/problem+json;q=0.9, */*;q=0.8");
+
+	output.replaceChildren(el("p", {}, "Sending…"));
+	const url = path + (query.toString() ? "?" + query : "");
+	try {
+		let body;
+		if (operation.requestBody) {
+			const contentType = form.elements.contentType.value;
+			body = form.elements.body.value;
+			if (contentType === "application/x-www-form-urlencoded") {
+				body = new URLSearchParams(Object.entries(JSON.parse(body || "{}")).filter(([, value]) => value !== ""));
+			} else if (contentType === "multipart/form-data") {
+				body = new FormData();
+				body.append("file", form.elements.file.files[0]);
+			} else if (!contentType.includes("json")) {
+				body = form.elements.file.files[0];
+			}
+			if (contentType !== "multipart/form-data") {
+				headers.set("Content-Type", contentType);
+			}
+		}
+
+		const response = await fetch(url, { method: method.toUpperCase(), headers, body });
+		const type = response.headers.get("Content-Type") || "";
+		let text;
+		if (/json|xml|text/.test(type)) {
+			text = await response.text();
+			if (type.includes("json") && text) {
+				text = JSON.stringify(JSON.parse(text), null, 2);
+			}
+		} else {
+			text = (await response.arrayBuffer()).byteLength + " bytes of " + (type || "unknown content");
+		}
+		output.replaceChildren(
+			el("p", {}, el("span", { class: response.ok ? "status" : "status error" }, response.status + " " + response.statusText), " ", method.toUpperCase() + " " + url),
+			el("pre", {}, Array.from(response.headers, ([name, value]) => name + ": " + value).join("\n")),
+			text ? el("pre", {}, text) : null);
+	} catch (error) {
+		output.replaceChildren(el("p", { class: "status error" }, String(error)));
+	}
+}
+
+function renderOperation(method, path, operation) {
+	const output = el("div");
+	const form = el("form", {
+		onsubmit: (event) => {
+			event.preventDefault();
+			send(method, path, operation, form, output);
+		},
+	});
+
+	const parameters = operation.parameters || [];
+	if (parameters.length) {
+		form.append(el("h4", {}, "Parameters"), el("table", {},
+			parameters.map((parameter) => el("tr", {},
+				el("td", { class: parameter.required ? "required" : false }, parameter.name, el("br"), el("small", {}, parameter.in)),
+				el("td", {}, parameterInput(parameter)),
+				el("td", {}, parameter.description || "")))));
+	}
+
+	if (operation.requestBody) {
+		const content = operation.requestBody.content;
+		const body = el("textarea", { name: "body", spellcheck: "false" });
+		const schema = el("div");
+		const file = el("input", { name: "file", type: "file", class: "hidden" });
+		const contentType = el("select", { name: "contentType" },
+			Object.keys(content).filter((type) => /json|form|csv|sheet/.test(type)).map((type) => el("option", { value: type }, type)));
+		const update = () => {
+			const type = contentType.value;
+			const binary = !type.includes("json") && type !== "application/x-www-form-urlencoded";
+			body.classList.toggle("hidden", binary);
+			file.classList.toggle("hidden", !binary);
+			body.value = JSON.stringify(example(content[type].schema, true), null, 2);
+			schema.replaceChildren(describe(content[type].schema));
+		};
+		contentType.addEventListener("change", update);
+		form.append(el("h4", {}, "Body"), contentType, schema, body, file);
+		update();
+	}
+
+	form.append(el("p", {}, el("button", { type: "submit" }, "Send")));
+
+	const responses = Object.entries(operation.responses).map(([status, response]) => {
+		const content = response.content || {};
+		const type = Object.keys(content).find((type) => type.includes("json")) || Object.keys(content)[0];
+		return el("details", {},
+			el("summary", {}, status + " " + response.description + (type && type !== "application/json" ? " (" + type + ")" : "")),
+			type ? describe(content[type].schema) : null);
+	});
+
+	return el("details", { class: "operation", "data-search": [method, path, operation.summary, ...(operation.tags || [])].join(" ").toLowerCase() },
+		el("summary", {},
+			el("span", { class: "method " + method }, method),
+			el("span", { class: "path" }, path),
+			el("span", {}, operation.summary || ""),
+			operation.security && operation.security.length === 0 ? el("span", { class: "public" }, "public") : null),
+		el("div", {},
+			operation.description ? el("p", {}, operation.description) : null,
+			form,
+			el("h4", {}, "Responses"),
+			responses,
+			el("h4", {}, "Result"),
+			output));
+}
+
+function render() {
+	document.title = spec.info.title;
+	document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+	document.getElementById("description").textContent = spec.info.description || "";
+
+	const groups = new Map();
+	for (const [path, item] of Object.entries(spec.paths).sort()) {
+		for (const method of methods.filter((method) => item[method])) {
+			const tag = (item[method].tags || ["other"])[0];
+			if (!groups.has(tag)) {
+				groups.set(tag, []);
+			}
+			groups.get(tag).push(renderOperation(method, path, item[method]));
+		}
+	}
+	document.getElementById("operations").replaceChildren(
+		...Array.from(groups).sort().flatMap(([tag, operations]) => [el("h2", {}, tag.replace(/_/g, " ")), ...operations]));
+}
+
+function setUp() {
+	const fields = credentials();
+	for (const name of ["bearer", "apiKey"]) {
+		fields[name].value = sessionStorage.getItem(name) || "";
+		fields[name].addEventListener("change", () => sessionStorage.setItem(name, fields[name].value));
+	}
+
+	const types = new Set();
+	for (const item of Object.values(spec.paths)) {
+		for (const method of methods.filter((method) => item[method])) {
+			for (const response of Object.values(item[method].responses)) {
+				Object.keys(response.content || {}).filter((type) => !type.startsWith("application/problem")).forEach((type) => types.add(type));
+			}
+		}
+	}
+	fields.format.replaceChildren(...["application/json", ...[...types].filter((type) => type !== "application/json").sort()]
+		.map((type) => el("option", { value: type }, type)));
+
+	fields.filter.addEventListener("input", () => {
+		const words = fields.filter.value.toLowerCase().split(/\s+/).filter(Boolean);
+		for (const operation of document.querySelectorAll("details.operation")) {
+			operation.classList.toggle("hidden", !words.every((word) => operation.dataset.search.includes(word)));
+		}
+	});
+}
+
+fetch("/openapi.json")
+	.then((response) => {
+		if (!response.ok) {
+			throw new Error("GET /openapi.json: " + response.status);
+		}
+		return response.json();
+	})
+	.then((doc) => {
+		spec = doc;
+		setUp();
+		render();
+	})
+	.catch((error) => {
+		document.getElementById("operations").replaceChildren(el("p", { class: "status error" }, String(error)));
+	});
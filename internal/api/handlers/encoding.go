@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// Response bodies are written in the format the Accept header asks for (JSON, XML, MessagePack or
+// CBOR, see pkg/codec) and request bodies are read according to their Content-Type, with JSON as
+// the default for both. Requests accepting none of the formats get 406 Not Acceptable, bodies in
+// any other format 415 Unsupported Media Type. Error responses stay problem+json whatever the format.
+
+// writeResponse answers with status and response encoded in the negotiated format.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, response any) {
+	body, ok := encodeResponse(w, r, response)
+	if !ok {
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// encodeResponse encodes response in the format negotiated from the Accept header and sets the
+// Content-Type to match. It answers 406 or 500 itself and returns false when there is no body to write.
+func encodeResponse(w http.ResponseWriter, r *http.Request, response any) ([]byte, bool) {
+	c, ok := codec.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		responder.NotAcceptable(w, r, codec.MediaTypes()...)
+		return nil, false
+	}
+
+	var body bytes.Buffer
+	if err := c.Encode(&body, response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		responder.Error(w, r, "Error encoding response", http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", c.MediaType())
+	w.Header().Add("Vary", "Accept")
+	return body.Bytes(), true
+}
+
+// decodeBody decodes the request body into v. It answers 415 or 400 itself and reports whether v was decoded.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder, ok := bodyDecoder(w, r, v)
+	if !ok {
+		return false
+	}
+	if err := decoder.Decode(v); err != nil {
+		log.Printf("Invalid request payload: %v", err)
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// decodeFields decodes a request body of fields to set on a model, a pointer to a zero value of the
+// record type. Formats without types of their own, such as XML, are read against the model's fields.
+// It answers 415 or 400 itself and reports whether the fields were decoded.
+func decodeFields(w http.ResponseWriter, r *http.Request, model any) (map[string]any, bool) {
+	decoder, ok := bodyDecoder(w, r, model)
+	if !ok {
+		return nil, false
+	}
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		log.Printf("Invalid request payload: %v", err)
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return nil, false
+	}
+	return fields, true
+}
+
+// bodyDecoder returns a JSON decoder over the request body, transcoded from its Content-Type, for
+// callers that need decoder options. v is the value the body will be decoded into.
+// It answers 415 or 400 itself and returns false when the body cannot be read.
+func bodyDecoder(w http.ResponseWriter, r *http.Request, v any) (*json.Decoder, bool) {
+	c, ok := codec.ForContentType(r.Header.Get("Content-Type"))
+	if !ok {
+		responder.UnsupportedMediaType(w, r, codec.MediaTypes()...)
+		return nil, false
+	}
+	body, err := c.Transcode(r.Body, v)
+	if err != nil {
+		log.Printf("Invalid request payload: %v", err)
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return nil, false
+	}
+	return json.NewDecoder(body), true
+}
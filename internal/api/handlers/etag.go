@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// Optimistic concurrency: every versioned row carries a version that each write increments.
+// Single-record responses carry it as a strong ETag of the version and format, e.g. "3-json" or
+// "3-cbor", since each format is a different representation. Clients send it back in If-Match on
+// PUT, PATCH and DELETE, where any format of the current version matches, and get 412 Precondition
+// Failed when someone else changed the record in the meantime.
+// With REQUIRE_IF_MATCH=true, writes without If-Match are rejected with 428 Precondition Required.
+
+// versionETag returns the strong ETag of a record version in the format negotiated for r.
+func versionETag(r *http.Request, version int) string {
+	tag := strconv.Itoa(version)
+	if c, ok := codec.Negotiate(r.Header.Get("Accept")); ok {
+		_, subtype, _ := strings.Cut(c.MediaType(), "/")
+		tag += "-" + strings.TrimPrefix(subtype, "vnd.")
+	}
+	return `"` + tag + `"`
+}
+
+// etagVersion returns the record version of a strong ETag made by versionETag.
+func etagVersion(etag string) (int, bool) {
+	tag, ok := strings.CutPrefix(etag, `"`)
+	if !ok || !strings.HasSuffix(tag, `"`) {
+		return 0, false // weak or malformed
+	}
+	tag, _, _ = strings.Cut(strings.TrimSuffix(tag, `"`), "-")
+	version, err := strconv.Atoi(tag)
+	return version, err == nil
+}
+
+// etagMatches reports whether a comma separated If-Match/If-None-Match header lists etag or "*".
+// Weak validators match on their opaque tag, which is enough for GET revalidation.
+func etagMatches(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces If-Match against the current version of the record about to be written.
+// It writes 412 (or 428 when If-Match is required but missing) and returns false when the write must not proceed.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if os.Getenv("REQUIRE_IF_MATCH") == "true" {
+			responder.Error(w, r, "If-Match header required", http.StatusPreconditionRequired)
+			return false
+		}
+		return true
+	}
+
+	// Weak validators never satisfy If-Match (RFC 9110 section 13.1.1)
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if v, ok := etagVersion(candidate); ok && v == version {
+			return true
+		}
+	}
+
+	w.Header().Set("ETag", versionETag(r, version))
+	responder.Error(w, r, "Precondition failed: the record was modified by another request", http.StatusPreconditionFailed)
+	return false
+}
+
+// notModified sets the ETag header and, when If-None-Match matches it, answers 304 Not Modified.
+// It reports whether the response has been written.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeCollection writes a GET collection response with a weak ETag derived from its content,
+// answering 304 when the client's copy is still current. Each format has its own ETag.
+func writeCollection(w http.ResponseWriter, r *http.Request, response any) {
+	body, ok := encodeResponse(w, r, response)
+	if !ok {
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	if notModified(w, r, `W/"`+hex.EncodeToString(sum[:16])+`"`) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/dbmap"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// Exports: GET /students/export and GET /teachers/export stream the records matching the same
+// filters, sorting and ?include_deleted as the list endpoints, except that filters are optional.
+// The format comes from ?format=csv|ndjson|xlsx or, failing that, the Accept header; CSV is the default.
+//
+// Rows go from rows.Next() straight to the response, so memory use does not grow with the result:
+// CSV and NDJSON are flushed every exportFlushRows rows, and XLSX is built with excelize's stream
+// writer, which spills to a temporary file. Once streaming has started an error can no longer be
+// reported with a status code, so the connection is aborted instead of ending a truncated file cleanly.
+//
+// Text that a spreadsheet would read as a formula (see formulaLike) is neutralized: CSV cells get a
+// leading ' and XLSX cells are written as inline strings, which are never evaluated.
+
+const exportFlushRows = 500
+
+// exportFormats maps ?format= values to their media types.
+var exportFormats = map[string]string{
+	"csv":    "text/csv",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   xlsxMediaType,
+}
+
+// exportWriter writes the records of one export format. row gets both the record and its
+// column values; flush pushes buffered rows towards the client, close ends the document. header
+// must not write to the response yet, so that its failure can still be answered with an error.
+type exportWriter interface {
+	header(columns []string) error
+	row(item any, values []any) error
+	flush() error
+	close() error
+}
+
+// ExportStudentsHandler streams the students matching the list filters.
+func ExportStudentsHandler(w http.ResponseWriter, r *http.Request) {
+	ExportHandler[models.Student](w, r, Students.Table)
+}
+
+// ExportTeachersHandler streams the teachers matching the list filters.
+func ExportTeachersHandler(w http.ResponseWriter, r *http.Request) {
+	ExportHandler[models.Teacher](w, r, Teachers.Table)
+}
+
+// ExportHandler is a generic handler streaming the records of table selected by the list filters.
+// The columns read are the fields of T with a db tag; see pkg/dbmap.
+func ExportHandler[T models.Model](w http.ResponseWriter, r *http.Request, table string) {
+	format, ok := exportFormat(r)
+	if !ok {
+		responder.NotAcceptable(w, r, exportFormats["csv"], exportFormats["ndjson"], exportFormats["xlsx"])
+		return
+	}
+	withDeleted, ok := includeDeleted(w, r, table)
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	var model T
+	query, args, err := addFilters(r, `SELECT `+dbmap.List(model)+` FROM `+table, nil, model)
+	filtered := err == nil
+	if sd, ok := any(model).(models.SoftDeletable); ok && !withDeleted {
+		if filtered {
+			query += " AND " + sd.DeletedAtColumn() + " IS NULL"
+		} else {
+			query += " WHERE " + sd.DeletedAtColumn() + " IS NULL"
+		}
+	}
+	query = addSorting(r, query, model)
+
+	rows, err := db.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		log.Printf("Error querying %s: %v", table, err)
+		responder.Error(w, r, "Error querying data.", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var out exportWriter
+	switch format {
+	case "csv":
+		out = &csvExport{writer: csv.NewWriter(w)}
+	case "ndjson":
+		out = &ndjsonExport{encoder: json.NewEncoder(w)}
+	case "xlsx":
+		workbook := excelize.NewFile()
+		defer workbook.Close()
+		out = &xlsxExport{file: workbook, w: w}
+	}
+
+	fields := exportFields(reflect.TypeFor[T]())
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	if err := out.header(names); err != nil {
+		log.Printf("Error exporting %s: %v", table, err)
+		responder.Error(w, r, "Error starting export", http.StatusInternalServerError)
+		return
+	}
+	filename := fmt.Sprintf("%s-%s.%s", table, time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Type", exportFormats[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	controller := http.NewResponseController(w)
+	count := 0
+	for rows.Next() {
+		var item T
+		if err := dbmap.Scan(rows, &item); err != nil {
+			abortExport(table, err)
+		}
+		value := reflect.ValueOf(item)
+		values := make([]any, len(fields))
+		for i, f := range fields {
+			values[i] = exportValue(value.Field(f.index))
+		}
+		if err := out.row(item, values); err != nil {
+			abortExport(table, err)
+		}
+
+		count++
+		if count%exportFlushRows == 0 {
+			if err := out.flush(); err != nil {
+				abortExport(table, err)
+			}
+			controller.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		abortExport(table, err)
+	}
+	if err := out.close(); err != nil {
+		abortExport(table, err)
+	}
+}
+
+// exportFormat picks the export format from ?format= or the Accept header.
+func exportFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		_, ok := exportFormats[format]
+		return format, ok
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return "csv", true
+	}
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "text/*" {
+			return "csv", true
+		}
+		for format, exportType := range exportFormats {
+			if mediaType == exportType {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// abortExport ends an export whose response has already started, so that the client sees a
+// broken transfer rather than a complete-looking file.
+func abortExport(table string, err error) {
+	log.Printf("Error exporting %s: %v", table, err)
+	panic(http.ErrAbortHandler)
+}
+
+// exportField is a struct field included in exports, named by its JSON name.
+type exportField struct {
+	index int
+	name  string
+}
+
+// exportFields lists the exported fields of a model in declaration order.
+func exportFields(t reflect.Type) []exportField {
+	var fields []exportField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, exportField{index: i, name: name})
+	}
+	return fields
+}
+
+// exportValue dereferences pointers (nil becomes an empty cell) and formats times as RFC 3339.
+func exportValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return v.Interface()
+}
+
+// formulaLike reports whether a text value starts like a spreadsheet formula: =, +, -, @, tab or
+// carriage return. Numbers are not text values and are left alone.
+func formulaLike(value any) bool {
+	text, ok := value.(string)
+	return ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0]))
+}
+
+// csvExport writes a header line followed by one line per record.
+type csvExport struct {
+	writer *csv.Writer
+}
+
+func (e *csvExport) header(columns []string) error {
+	return e.writer.Write(columns)
+}
+
+func (e *csvExport) row(_ any, values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch {
+		case formulaLike(value):
+			record[i] = "'" + value.(string)
+		case value != nil:
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return e.writer.Write(record)
+}
+
+func (e *csvExport) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExport) close() error {
+	return e.flush()
+}
+
+// ndjsonExport writes one JSON record per line, encoded like the list endpoints encode it.
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExport) header([]string) error {
+	return nil
+}
+
+func (e *ndjsonExport) row(item any, _ []any) error {
+	return e.encoder.Encode(item)
+}
+
+func (e *ndjsonExport) flush() error {
+	return nil
+}
+
+func (e *ndjsonExport) close() error {
+	return nil
+}
+
+// xlsxExport streams rows into the first sheet of a workbook written to w on close.
+type xlsxExport struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	w      http.ResponseWriter
+	rowNum int
+}
+
+func (e *xlsxExport) header(columns []string) error {
+	stream, err := e.file.NewStreamWriter(e.file.GetSheetName(0))
+	if err != nil {
+		return err
+	}
+	e.stream = stream
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return e.row(nil, values)
+}
+
+func (e *xlsxExport) row(_ any, values []any) error {
+	e.rowNum++
+	cell, err := excelize.CoordinatesToCellName(1, e.rowNum)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if formulaLike(value) {
+			values[i] = []excelize.RichTextRun{{Text: value.(string)}}
+		}
+	}
+	return e.stream.SetRow(cell, values)
+}
+
+// flush is a no-op: the workbook can only be written once complete.
+func (e *xlsxExport) flush() error {
+	return nil
+}
+
+func (e *xlsxExport) close() error {
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// Roster imports: POST /students/import and POST /teachers/import accept a CSV or XLSX spreadsheet,
+// either as the raw body (Content-Type text/csv or the XLSX media type) or as the "file" part of a
+// multipart/form-data upload. The first row is the header; the first sheet of a workbook is used.
+//
+// Columns map to fields by header name: "First Name" fills first_name. An explicit mapping from
+// header to field can be sent as JSON in the "mapping" query parameter or form field (before the
+// file part), e.g. {"Given name": "first_name", "Room": "room_number"}; unmapped columns are ignored.
+// A room_number column resolves the classroom when no classroom_id is given.
+//
+// Rows are read and inserted one at a time, so large files are never held in memory as a whole
+// (workbooks are spooled to a temporary file, as XLSX needs random access). Every row is validated
+// like a POST payload and failing rows are reported by their row number in the sheet:
+//
+//	?dry_run=true     validate and insert inside a transaction that is always rolled back (200)
+//	(default)         all-or-nothing: any failing row rolls back the whole import (201 or 422)
+//	?mode=partial     commit the valid rows and report the failing ones (207)
+
+// MaxImportSize is the largest spreadsheet an import accepts.
+const MaxImportSize = 50 << 20
+
+const (
+	maxImportMapping   = 64 << 10
+	maxReportedRowErrs = 1000
+	xlsxMediaType      = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// rowReader yields spreadsheet rows, returning io.EOF after the last one. *csv.Reader is one.
+type rowReader interface {
+	Read() ([]string, error)
+}
+
+// xlsxRows streams the rows of a worksheet.
+type xlsxRows struct {
+	rows *excelize.Rows
+}
+
+func (x xlsxRows) Read() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return x.rows.Columns()
+}
+
+// importSpec describes how rows of one resource are inserted.
+type importSpec[T any] struct {
+	insertQuery string
+	insert      func(r *http.Request, tx *sql.Tx, stmt *sql.Stmt, item T) (T, error)
+}
+
+// importRowError reports a row that could not be imported.
+type importRowError struct {
+	Row   int                `json:"row"`
+	Error *responder.Problem `json:"error"`
+}
+
+// importResult is the body of an import response.
+type importResult struct {
+	Status          string           `json:"status"`
+	DryRun          bool             `json:"dry_run"`
+	Rows            int              `json:"rows"`
+	Valid           int              `json:"valid"`
+	Failed          int              `json:"failed"`
+	Imported        int              `json:"imported"`
+	Errors          []importRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+// ImportStudentsHandler imports students from a CSV or XLSX roster.
+func ImportStudentsHandler(w http.ResponseWriter, r *http.Request) {
+	importRecords(w, r, importSpec[models.Student]{
+		insertQuery: Students.insertQuery(),
+		insert:      Students.insert,
+	})
+}
+
+// ImportTeachersHandler imports teachers from a CSV or XLSX roster.
+func ImportTeachersHandler(w http.ResponseWriter, r *http.Request) {
+	importRecords(w, r, importSpec[models.Teacher]{
+		insertQuery: Teachers.insertQuery(),
+		insert:      Teachers.insert,
+	})
+}
+
+// importRecords streams the uploaded spreadsheet into the resource's table within one transaction.
+func importRecords[T any](w http.ResponseWriter, r *http.Request, spec importSpec[T]) {
+	partial, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+	rows, mapping, cleanup, err := openImport(r)
+	if err != nil {
+		writeItemError(w, r, err)
+		return
+	}
+	defer cleanup()
+
+	header, err := rows.Read()
+	if err == io.EOF {
+		responder.Error(w, r, "The spreadsheet is empty", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error reading spreadsheet: %v", err)
+		responder.Error(w, r, "Error reading spreadsheet: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var zero T
+	columns, err := importColumns(header, mapping, zero)
+	if err != nil {
+		responder.Error(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(r.Context(), spec.insertQuery)
+	if err != nil {
+		log.Printf("Error preparing SQL statement: %v", err)
+		responder.Error(w, r, "Error preparing SQL statement", http.StatusInternalServerError)
+		return
+	}
+	defer stmt.Close()
+
+	validate := payloadValidator(tx)
+	rooms := make(map[string]int)
+	var rowErrs []importRowError
+	total, failed := 0, 0
+	for rowNumber := 2; ; rowNumber++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Printf("Error reading spreadsheet row %d: %v", rowNumber, err)
+			responder.Error(w, r, fmt.Sprintf("Error reading spreadsheet row %d: %v", rowNumber, err), http.StatusBadRequest)
+			return
+		}
+		if blankRow(record) {
+			continue
+		}
+		total++
+
+		var rowErr error
+		err = withSavepoint(r.Context(), tx, func() bool {
+			var item T
+			cellErrs, err := decodeRow(r.Context(), tx, &item, columns, record, rooms)
+			if err != nil {
+				rowErr = err
+				return true
+			}
+			if rowErr = mergeRowErrors(cellErrs, validateItem(r, validate, item)); rowErr != nil {
+				return true
+			}
+			_, rowErr = spec.insert(r, tx, stmt, item)
+			return rowErr != nil
+		})
+		if err != nil {
+			responder.Error(w, r, "Error processing import", http.StatusInternalServerError)
+			return
+		}
+		if rowErr != nil {
+			failed++
+			if len(rowErrs) < maxReportedRowErrs {
+				rowErrs = append(rowErrs, importRowError{Row: rowNumber, Error: failedItem(rowNumber, rowErr).Error})
+			}
+		}
+	}
+
+	status := http.StatusCreated
+	switch {
+	case dryRun:
+		status = http.StatusOK
+	case failed > 0 && !partial:
+		status = http.StatusUnprocessableEntity
+	case partial:
+		status = http.StatusMultiStatus
+	}
+	imported := 0
+	if status == http.StatusCreated || status == http.StatusMultiStatus {
+		// Commit transaction
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing transaction: %v", err)
+			responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+			return
+		}
+		imported = total - failed
+	}
+
+	result := "success"
+	switch {
+	case failed == total && failed > 0:
+		result = "error"
+	case failed > 0:
+		result = "partial"
+	}
+
+	response := importResult{
+		Status:          result,
+		DryRun:          dryRun,
+		Rows:            total,
+		Valid:           total - failed,
+		Failed:          failed,
+		Imported:        imported,
+		Errors:          rowErrs,
+		ErrorsTruncated: failed > len(rowErrs),
+	}
+	if response.Errors == nil {
+		response.Errors = []importRowError{}
+	}
+	writeResponse(w, r, status, response)
+}
+
+// openImport finds the uploaded spreadsheet in r and returns a reader over its rows, the column
+// mapping sent with it, and a cleanup function to call once the rows have been read.
+func openImport(r *http.Request) (rowReader, map[string]string, func(), error) {
+	rawMapping := r.URL.Query().Get("mapping")
+	body := io.Reader(r.Body)
+	filename := ""
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, nil, &itemError{http.StatusUnsupportedMediaType, "Invalid Content-Type"}
+	}
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, nil, nil, &itemError{http.StatusBadRequest, "Invalid multipart body"}
+		}
+		body = nil
+		for body == nil {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, nil, nil, &itemError{http.StatusBadRequest, `Missing "file" part`}
+			} else if err != nil {
+				return nil, nil, nil, &itemError{http.StatusBadRequest, "Invalid multipart body"}
+			}
+			switch part.FormName() {
+			case "mapping":
+				data, err := io.ReadAll(io.LimitReader(part, maxImportMapping))
+				if err != nil {
+					return nil, nil, nil, &itemError{http.StatusBadRequest, "Invalid mapping"}
+				}
+				rawMapping = string(data)
+			case "file":
+				body, filename = part, part.FileName()
+				mediaType, _, _ = mime.ParseMediaType(part.Header.Get("Content-Type"))
+			}
+		}
+	}
+
+	var mapping map[string]string
+	if rawMapping != "" {
+		if err := json.Unmarshal([]byte(rawMapping), &mapping); err != nil {
+			return nil, nil, nil, &itemError{http.StatusBadRequest, `Invalid mapping: expected a JSON object of "header": "field"`}
+		}
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); {
+	case ext == ".csv" || mediaType == "text/csv" || mediaType == "application/csv":
+		reader := csv.NewReader(body)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader, mapping, func() {}, nil
+	case ext == ".xlsx" || mediaType == xlsxMediaType:
+		rows, cleanup, err := openWorkbook(body)
+		return rows, mapping, cleanup, err
+	}
+	return nil, nil, nil, &itemError{http.StatusUnsupportedMediaType, "Unsupported spreadsheet: upload a .csv or .xlsx file"}
+}
+
+// openWorkbook spools an XLSX upload to a temporary file and streams the rows of its first sheet.
+func openWorkbook(body io.Reader) (rowReader, func(), error) {
+	tmp, err := os.CreateTemp("", "import-*.xlsx")
+	if err != nil {
+		log.Printf("Error creating temporary file: %v", err)
+		return nil, nil, &itemError{http.StatusInternalServerError, "Error reading spreadsheet"}
+	}
+	removeTmp := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		removeTmp()
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, nil, &itemError{http.StatusRequestEntityTooLarge, "Spreadsheet too large"}
+		}
+		return nil, nil, &itemError{http.StatusBadRequest, "Error reading upload"}
+	}
+
+	workbook, err := excelize.OpenFile(tmp.Name())
+	if err != nil {
+		removeTmp()
+		return nil, nil, &itemError{http.StatusBadRequest, "Invalid XLSX file"}
+	}
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		workbook.Close()
+		removeTmp()
+		return nil, nil, &itemError{http.StatusBadRequest, "The workbook has no sheets"}
+	}
+	rows, err := workbook.Rows(sheets[0])
+	if err != nil {
+		workbook.Close()
+		removeTmp()
+		return nil, nil, &itemError{http.StatusBadRequest, "Invalid XLSX file"}
+	}
+	return xlsxRows{rows}, func() {
+		rows.Close()
+		workbook.Close()
+		removeTmp()
+	}, nil
+}
+
+// importColumns resolves the header row into the field each column fills ("" for ignored columns).
+func importColumns(header []string, mapping map[string]string, model any) ([]string, error) {
+	fields := validator.WritableFields(model)
+	if slices.Contains(fields, "classroom_id") {
+		fields = append(fields, "room_number")
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		var field string
+		if mapping != nil {
+			field = mapping[name]
+			if field != "" && !slices.Contains(fields, field) {
+				return nil, fmt.Errorf("Invalid mapping for column %q: unknown field %q", name, field)
+			}
+		} else if normalized := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(name)); slices.Contains(fields, normalized) {
+			field = normalized
+		}
+		if field == "" {
+			continue
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("More than one column maps to %s", field)
+		}
+		seen[field] = true
+		columns[i] = field
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("No column matches a field; expected headers among: %s", strings.Join(fields, ", "))
+	}
+	return columns, nil
+}
+
+// blankRow reports whether every cell of a row is empty.
+func blankRow(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeRow fills dst, a pointer to a model, from the cells of a row. Cells are converted to the
+// field types and a room number is resolved to its classroom; rooms caches the lookups. Cells that
+// cannot be converted are returned as field errors; err is only set when a lookup failed.
+func decodeRow(ctx context.Context, tx *sql.Tx, dst any, columns, record []string, rooms map[string]int) (validator.Errors, error) {
+	values := make(map[string]string)
+	for i, field := range columns {
+		if field != "" && i < len(record) {
+			values[field] = strings.TrimSpace(record[i])
+		}
+	}
+
+	var errs validator.Errors
+	if room := values["room_number"]; room != "" && values["classroom_id"] == "" {
+		id, ok := rooms[room]
+		if !ok {
+			var err error
+			id, err = sqlconnect.ClassroomIDByRoomNumber(ctx, tx, room)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, &itemError{http.StatusInternalServerError, "Error looking up classroom"}
+			}
+			rooms[room] = id
+		}
+		if id == 0 {
+			errs = append(errs, validator.FieldError{Field: "room_number", Rule: "exists", Message: "no classroom with room number " + room})
+		} else {
+			values["classroom_id"] = strconv.Itoa(id)
+		}
+	}
+	delete(values, "room_number")
+
+	value := reflect.ValueOf(dst).Elem()
+	for i := range value.NumField() {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		cell, ok := values[name]
+		if !ok || cell == "" {
+			continue
+		}
+		switch field := value.Field(i); field.Kind() {
+		case reflect.String:
+			field.SetString(cell)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(cell, 10, 64)
+			if err != nil {
+				errs = append(errs, validator.FieldError{Field: name, Rule: "type", Message: "must be an integer"})
+				continue
+			}
+			field.SetInt(n)
+		}
+	}
+	return errs, nil
+}
+
+// mergeRowErrors combines the cell errors of a row with the outcome of validating it. Validation
+// errors of fields whose cell already failed (such as a missing classroom_id after an unknown
+// room_number) are dropped, as they only restate the cell error.
+func mergeRowErrors(cellErrs validator.Errors, err error) error {
+	fieldErrs, ok := err.(validator.Errors)
+	if err != nil && !ok {
+		return err
+	}
+	if len(cellErrs) == 0 {
+		return err
+	}
+
+	failed := make(map[string]bool)
+	for _, cellErr := range cellErrs {
+		failed[cellErr.Field] = true
+	}
+	if failed["room_number"] {
+		failed["classroom_id"] = true
+	}
+	for _, fieldErr := range fieldErrs {
+		if !failed[fieldErr.Field] {
+			cellErrs = append(cellErrs, fieldErr)
+		}
+	}
+	return cellErrs
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// WhoAmIHandler echoes the identity the server resolved for the caller, so integration partners
+// can check their client certificate or API key is mapped as expected.
+func WhoAmIHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := utils.PrincipalFromContext(r.Context())
+	if !ok {
+		responder.Error(w, r, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	response := struct {
+		Status string   `json:"status"`
+		Kind   string   `json:"kind"`
+		Name   string   `json:"name"`
+		Role   string   `json:"role,omitempty"`
+		Scopes []string `json:"scopes,omitempty"`
+	}{
+		Status: "success",
+		Kind:   principal.Kind,
+		Name:   principal.Username,
+		Role:   principal.Role,
+		Scopes: principal.Scopes,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// Lockout policy: after LOGIN_LOCKOUT_THRESHOLD consecutive failures (default 5) a username is locked
+// for LOGIN_LOCKOUT_BASE (default 1m), doubling with each further failure up to LOGIN_LOCKOUT_MAX (default 1h).
+// Tracking is per username rather than per IP so distributed attempts against one account still lock it.
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash returns a hash with the current parameters, used to spend the same time
+// verifying passwords for usernames that do not exist.
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := utils.HashPassword("dummy-password-for-timing")
+		if err != nil {
+			log.Printf("Error creating dummy password hash: %v", err)
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
+
+// normalizeUsername makes lockout tracking case-insensitive.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func lockoutThreshold() int {
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_THRESHOLD")); err == nil && n > 0 {
+		return n
+	}
+	return 5
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// lockoutDuration returns how long to lock a username after the given number of consecutive failures.
+func lockoutDuration(failures int) time.Duration {
+	threshold := lockoutThreshold()
+	if failures < threshold {
+		return 0
+	}
+	base := envDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	maxLock := envDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+
+	exponent := min(failures-threshold, 30)
+	d := time.Duration(float64(base) * math.Pow(2, float64(exponent)))
+	return min(d, maxLock)
+}
+
+// rejectIfLocked writes a 429 with Retry-After and returns true if username is currently locked out.
+func rejectIfLocked(w http.ResponseWriter, r *http.Request, db *sql.DB, username string) bool {
+	lockedUntil, err := sqlconnect.GetLockedUntil(r.Context(), db, username)
+	if err != nil || !time.Now().Before(lockedUntil) {
+		return false
+	}
+	retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	responder.Error(w, r, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return true
+}
+
+// registerLoginFailure counts a failed attempt and locks the username once the threshold is reached.
+func registerLoginFailure(r *http.Request, db *sql.DB, username string) {
+	ctx := context.WithoutCancel(r.Context())
+	failures, err := sqlconnect.RecordLoginFailure(ctx, db, username)
+	if err != nil {
+		return
+	}
+
+	lock := lockoutDuration(failures)
+	if lock == 0 {
+		return
+	}
+
+	until := time.Now().Add(lock)
+	if err := sqlconnect.LockLogin(ctx, db, username, until); err != nil {
+		return
+	}
+	log.Printf("Locked login for %s until %s after %d failed attempts", username, until.UTC().Format(time.RFC3339), failures)
+
+	changes, _ := json.Marshal(map[string]any{
+		"failed_attempts": failures,
+		"locked_until":    until.UTC(),
+		"remote_addr":     r.RemoteAddr,
+	})
+	sqlconnect.InsertAuditEntry(ctx, db, models.AuditEntry{
+		Actor:     "system",
+		Action:    "lockout",
+		Resource:  "login",
+		RecordID:  username,
+		Changes:   changes,
+		RequestID: utils.RequestIDFromContext(ctx),
+	})
+}
+
+// UnlockExecutiveHandler clears the lockout and failure count of the executive in the path.
+func UnlockExecutiveHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 {
+		responder.Error(w, r, "Invalid executive id", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	exec, err := sqlconnect.GetExecutiveByID(r.Context(), db, id)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "Executive not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+
+	username := normalizeUsername(exec.Username)
+	cleared, err := sqlconnect.ClearLoginFailures(r.Context(), db, username)
+	if err != nil {
+		responder.Error(w, r, "Error unlocking executive", http.StatusInternalServerError)
+		return
+	}
+
+	actor := "unknown"
+	if principal, ok := utils.PrincipalFromContext(r.Context()); ok {
+		actor = principal.Username
+	}
+	sqlconnect.InsertAuditEntry(r.Context(), db, models.AuditEntry{
+		Actor:     actor,
+		Action:    "unlock",
+		Resource:  "login",
+		RecordID:  username,
+		Changes:   json.RawMessage(fmt.Sprintf(`{"was_tracked":%t}`, cleared > 0)),
+		RequestID: utils.RequestIDFromContext(r.Context()),
+	})
+
+	response := struct {
+		Status string `json:"status"`
+		ID     int    `json:"id"`
+	}{
+		Status: "success",
+		ID:     id,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
 	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/dbmap"
 	"github.com/jorge-sader/go-rest-api/pkg/responder"
 )
 
@@ -93,31 +93,41 @@ func addFilters(r *http.Request, query string, args []any, model interface{}) (s
 }
 
 // GetManyHandler is a generic handler for retrieving multiple records of any model.
-func GetManyHandler[T models.Model](w http.ResponseWriter, r *http.Request, table string, scanFunc func(*T, *sql.Rows) error) {
+// The columns read are the fields of T with a db tag; see pkg/dbmap.
+// Soft-deleted rows of models.SoftDeletable tables are skipped unless includeDeleted allows them.
+func GetManyHandler[T models.Model](w http.ResponseWriter, r *http.Request, table string) {
+	withDeleted, ok := includeDeleted(w, r, table)
+	if !ok {
+		return
+	}
+
 	db, err := sqlconnect.ConnectDB()
 	if err != nil {
 		log.Printf("Error connecting to database: %v", err)
-		http.Error(w, "Error connecting to database", http.StatusInternalServerError)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
 		return
 	}
 	defer db.Close()
 
-	query := `SELECT * FROM ` + table
+	var model T
+	query := `SELECT ` + dbmap.List(model) + ` FROM ` + table
 	var args []any
 
-	var model T
 	query, args, err = addFilters(r, query, args, model)
 	if err != nil {
 		log.Printf("Invalid request: %v", err)
-		http.Error(w, "At least one valid filter is required", http.StatusBadRequest)
+		responder.Error(w, r, "At least one valid filter is required", http.StatusBadRequest)
 		return
 	}
+	if sd, ok := any(model).(models.SoftDeletable); ok && !withDeleted {
+		query += " AND " + sd.DeletedAtColumn() + " IS NULL"
+	}
 	query = addSorting(r, query, model)
 
 	rows, err := db.QueryContext(r.Context(), query, args...)
 	if err != nil {
 		log.Printf("Error querying %s: %v", table, err)
-		http.Error(w, "Error querying data.", http.StatusInternalServerError)
+		responder.Error(w, r, "Error querying data.", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
@@ -125,17 +135,17 @@ func GetManyHandler[T models.Model](w http.ResponseWriter, r *http.Request, tabl
 	list := make([]T, 0)
 	for rows.Next() {
 		var item T
-		err := scanFunc(&item, rows)
+		err := dbmap.Scan(rows, &item)
 		if err != nil {
 			log.Printf("Error scanning database results: %v", err)
-			http.Error(w, "Error scanning database results.", http.StatusInternalServerError)
+			responder.Error(w, r, "Error scanning database results.", http.StatusInternalServerError)
 			return
 		}
 		list = append(list, item)
 	}
 	if err := rows.Err(); err != nil {
 		log.Printf("Error iterating over database results: %v", err)
-		http.Error(w, "Error iterating over database results.", http.StatusInternalServerError)
+		responder.Error(w, r, "Error iterating over database results.", http.StatusInternalServerError)
 		return
 	}
 
@@ -148,45 +158,52 @@ func GetManyHandler[T models.Model](w http.ResponseWriter, r *http.Request, tabl
 		Count:  len(list),
 		Data:   list,
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeCollection(w, r, response)
 }
 
 // GetOneHandler is a generic handler for retrieving a single record of any model.
-func GetOneHandler[T models.Model](w http.ResponseWriter, r *http.Request, table string, scanFunc func(*T, *sql.Row) error) {
+// The columns read are the fields of T with a db tag; see pkg/dbmap.
+// Soft-deleted rows of models.SoftDeletable tables are skipped unless includeDeleted allows them.
+func GetOneHandler[T models.Model](w http.ResponseWriter, r *http.Request, table string) {
+	withDeleted, ok := includeDeleted(w, r, table)
+	if !ok {
+		return
+	}
+
 	db, err := sqlconnect.ConnectDB()
 	if err != nil {
 		log.Printf("Error connecting to database: %v", err)
-		http.Error(w, "Error connecting to database", http.StatusInternalServerError)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
 		return
 	}
 	defer db.Close()
 
-	query := `SELECT * FROM ` + table
+	var model T
+	query := `SELECT ` + dbmap.List(model) + ` FROM ` + table
 	var args []any
 
-	var model T
 	query, args, err = addFilters(r, query, args, model)
 	if err != nil {
 		log.Printf("Invalid request: %v", err)
-		http.Error(w, "At least one valid filter is required", http.StatusBadRequest)
+		responder.Error(w, r, "At least one valid filter is required", http.StatusBadRequest)
 		return
 	}
+	if sd, ok := any(model).(models.SoftDeletable); ok && !withDeleted {
+		query += " AND " + sd.DeletedAtColumn() + " IS NULL"
+	}
 
 	// Limit to one record
 	query += " LIMIT 1"
 
 	row := db.QueryRowContext(r.Context(), query, args...)
 	var item T
-	err = scanFunc(&item, row)
+	err = dbmap.Scan(row, &item)
 	if err == sql.ErrNoRows {
-		responder.RespondNoRecordFound(w)
+		responder.RespondNoRecordFound(w, r)
 		return
 	} else if err != nil {
 		log.Printf("Error scanning %s: %v", table, err)
-		http.Error(w, "Error retrieving data.", http.StatusInternalServerError)
+		responder.Error(w, r, "Error retrieving data.", http.StatusInternalServerError)
 		return
 	}
 
@@ -198,9 +215,7 @@ func GetOneHandler[T models.Model](w http.ResponseWriter, r *http.Request, table
 		Data:   item,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, r, http.StatusOK, response)
 }
 
 // func addSorting(r *http.Request, query string) string {
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// oauthTokenTTL reads OAUTH_TOKEN_EXPIRES_IN (e.g. "1h"), defaulting to one hour.
+func oauthTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("OAUTH_TOKEN_EXPIRES_IN"))
+	if err != nil || ttl <= 0 {
+		return time.Hour
+	}
+	return ttl
+}
+
+// writeOAuthError writes an RFC 6749 section 5.2 error response.
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// authenticateOAuthClient verifies client credentials sent with HTTP Basic authentication
+// (preferred) or as client_id/client_secret form parameters. r.ParseForm must have been called.
+func authenticateOAuthClient(r *http.Request, db *sql.DB) (models.OAuthClient, bool) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		return models.OAuthClient{}, false
+	}
+
+	client, err := sqlconnect.GetOAuthClientByClientID(r.Context(), db, clientID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error querying OAuth client %s: %v", clientID, err)
+		}
+		return models.OAuthClient{}, false
+	}
+	if client.RevokedAt != nil {
+		return models.OAuthClient{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return models.OAuthClient{}, false
+	}
+	return client, true
+}
+
+// TokenHandler implements the OAuth2 token endpoint for the client_credentials grant (RFC 6749 section 4.4).
+// The requested scope must be a subset of the client's registered scopes; when omitted all of them are granted.
+func TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Malformed form body")
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+	defer db.Close()
+
+	client, ok := authenticateOAuthClient(r, db)
+	if !ok {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+
+	scopes := client.Scopes
+	if requested := strings.Fields(r.PostForm.Get("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(client.Scopes, scope) {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "Scope not allowed for this client: "+scope)
+				return
+			}
+		}
+		scopes = requested
+	}
+
+	ttl := oauthTokenTTL()
+	token, _, err := utils.SignOAuthToken(client.ClientID, scopes, ttl)
+	if err != nil {
+		log.Printf("Error signing OAuth token: %v", err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	response := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+		return
+	}
+}
+
+// IntrospectHandler implements OAuth2 token introspection (RFC 7662). Callers authenticate as a
+// registered client. Tokens that are invalid, expired or belong to a revoked client are reported inactive.
+func IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Malformed form body")
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+	defer db.Close()
+
+	if _, ok := authenticateOAuthClient(r, db); !ok {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	type introspection struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		Exp       int64  `json:"exp,omitempty"`
+		Iat       int64  `json:"iat,omitempty"`
+		Sub       string `json:"sub,omitempty"`
+		Iss       string `json:"iss,omitempty"`
+		Jti       string `json:"jti,omitempty"`
+	}
+	response := introspection{Active: false}
+
+	if claims, err := utils.ParseOAuthToken(token); err == nil {
+		owner, err := sqlconnect.GetOAuthClientByClientID(r.Context(), db, claims.ClientID)
+		if err == nil && owner.RevokedAt == nil {
+			response = introspection{
+				Active:    true,
+				Scope:     claims.Scope,
+				ClientID:  claims.ClientID,
+				TokenType: "Bearer",
+				Exp:       claims.ExpiresAt.Unix(),
+				Iat:       claims.IssuedAt.Unix(),
+				Sub:       claims.Subject,
+				Iss:       claims.Issuer,
+				Jti:       claims.ID,
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+		return
+	}
+}
+
+// JWKSHandler publishes the public OAuth2 signing keys (RFC 7517) for offline token verification.
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	jwks, err := utils.JWKS()
+	if err != nil {
+		log.Printf("Error building JWKS: %v", err)
+		responder.Error(w, r, "Error building JWKS", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jwks)
+}
+
+// CreateOAuthClientHandler registers an OAuth2 client. The client secret is only returned in this response.
+func CreateOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		responder.Error(w, r, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if !validateScopes(req.Scopes) {
+		responder.Error(w, r, `At least one scope of the form "<resource>:<action>" is required`, http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := utils.RandomHex(12)
+	if err != nil {
+		responder.Error(w, r, "Error generating client id", http.StatusInternalServerError)
+		return
+	}
+	secret, secretHash, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		responder.Error(w, r, "Error generating client secret", http.StatusInternalServerError)
+		return
+	}
+
+	createdBy := "unknown"
+	if principal, ok := utils.PrincipalFromContext(r.Context()); ok {
+		createdBy = principal.Username
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	client := models.OAuthClient{
+		ClientID:   clientID,
+		SecretHash: secretHash,
+		Name:       strings.TrimSpace(req.Name),
+		Scopes:     req.Scopes,
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now().UTC(),
+	}
+	client.ID, err = sqlconnect.CreateOAuthClient(r.Context(), db, client)
+	if err != nil {
+		responder.Error(w, r, "Error creating OAuth client", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status       string             `json:"status"`
+		ClientSecret string             `json:"client_secret"`
+		Data         models.OAuthClient `json:"data"`
+	}{
+		Status:       "success",
+		ClientSecret: secret,
+		Data:         client,
+	}
+	writeResponse(w, r, http.StatusCreated, response)
+}
+
+// ListOAuthClientsHandler lists registered OAuth2 clients. Secrets are never returned.
+func ListOAuthClientsHandler(w http.ResponseWriter, r *http.Request) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	clients, err := sqlconnect.ListOAuthClients(r.Context(), db)
+	if err != nil {
+		log.Printf("Error querying OAuth clients: %v", err)
+		responder.Error(w, r, "Error querying OAuth clients", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string               `json:"status"`
+		Count  int                  `json:"count"`
+		Data   []models.OAuthClient `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(clients),
+		Data:   clients,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// RevokeOAuthClientHandler revokes a client. Its outstanding tokens introspect as inactive and are
+// rejected by this API, at once on this instance and within 30 seconds on others (see
+// middlewares.Authenticate), although services verifying offline accept them until they expire.
+func RevokeOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 {
+		responder.Error(w, r, "Invalid client id", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	rowsAffected, err := sqlconnect.RevokeOAuthClient(r.Context(), db, id)
+	if err != nil {
+		responder.Error(w, r, "Error revoking OAuth client", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		responder.Error(w, r, "OAuth client not found or already revoked", http.StatusNotFound)
+		return
+	}
+	middlewares.ForgetOAuthClients()
+
+	response := struct {
+		Status string `json:"status"`
+		ID     int    `json:"id"`
+	}{
+		Status: "success",
+		ID:     id,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
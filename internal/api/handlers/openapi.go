@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/dbmap"
+	"github.com/jorge-sader/go-rest-api/pkg/openapi"
+	"github.com/jorge-sader/go-rest-api/pkg/patch"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// The OpenAPI document at /openapi.json is built by the router as routes are registered: resource
+// routes carry their own description (Route.Doc, derived from the model), the others are described
+// in Operations. Bodies are given as values of the types the handlers read and write, so the schemas
+// follow the json and validate tags of the models; see pkg/openapi.
+
+// NewOpenAPIDocument returns the API's OpenAPI document, without operations.
+func NewOpenAPIDocument() *openapi.Document {
+	doc := openapi.New("School Management API", "1.0.0")
+	doc.Description = "Students, teachers, classrooms, subjects and the executives managing them. " +
+		"Bodies are exchanged as JSON, XML, MessagePack or CBOR, chosen with Content-Type and Accept; " +
+		"errors are RFC 7807 problem documents. POST and PATCH requests may carry an Idempotency-Key, " +
+		"except on the authentication and credential endpoints, which answer 400 to it."
+	doc.MediaTypes = codec.MediaTypes()
+	doc.Error, doc.ErrorType = responder.Problem{}, responder.MediaType
+	doc.SecuritySchemes = map[string]any{
+		"bearerAuth": map[string]any{
+			"type":         "http",
+			"scheme":       "bearer",
+			"bearerFormat": "JWT",
+			"description":  "An executive access token from /executives/login or an OAuth2 client-credentials token from /oauth/token.",
+		},
+		"apiKey": map[string]any{
+			"type":        "apiKey",
+			"in":          "header",
+			"name":        "X-API-Key",
+			"description": "An API key, also accepted as \"Authorization: ApiKey <key>\".",
+		},
+		"mutualTLS": map[string]any{
+			"type":        "mutualTLS",
+			"description": "A client certificate issued by the integrations CA.",
+		},
+	}
+	doc.Security = []string{"bearerAuth", "apiKey", "mutualTLS"}
+	doc.PathParameters = map[string]openapi.Parameter{"id": {Description: "The id of the record.", Type: 0}}
+	return doc
+}
+
+// OpenAPIHandler serves doc as JSON. The document is rendered on the first request, once every
+// route has been registered.
+func OpenAPIHandler(doc *openapi.Document) http.HandlerFunc {
+	render := sync.OnceValues(func() ([]byte, error) {
+		return json.Marshal(doc)
+	})
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := render()
+		if err != nil {
+			log.Printf("Error rendering OpenAPI document: %v", err)
+			responder.Error(w, r, "Error rendering OpenAPI document", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
+
+//go:embed docs
+var docsFiles embed.FS
+
+// DocsHandler serves the interactive API documentation embedded in the binary, a page that reads
+// /openapi.json and can send requests to the API. It must be mounted at /docs/.
+func DocsHandler() http.Handler {
+	files, err := fs.Sub(docsFiles, "docs")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/docs/", http.FileServerFS(files))
+}
+
+// dataEnvelope is the body of responses carrying one record.
+type dataEnvelope[T any] struct {
+	Status string `json:"status"`
+	Data   T      `json:"data"`
+}
+
+// listEnvelope is the body of responses carrying a list of records.
+type listEnvelope[T any] struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+	Data   []T    `json:"data"`
+}
+
+// idEnvelope is the body of responses naming the record acted upon.
+type idEnvelope struct {
+	Status string `json:"status"`
+	ID     int    `json:"id"`
+}
+
+// filterParameters describes the filters of T: the keys of FilterableFields, typed like the field
+// of the column they filter on.
+func filterParameters[T models.Model]() []openapi.Parameter {
+	var model T
+	var parameters []openapi.Parameter
+	filters := model.FilterableFields()
+	for _, name := range slices.Sorted(maps.Keys(filters)) {
+		parameter := openapi.Parameter{Name: name, In: "query", Type: "", Description: "Only the records whose " + name + " equals the value."}
+		if column, ok := dbmap.Find(model, filters[name]); ok {
+			parameter.Type = column.Value(model)
+		}
+		if name == "id" {
+			parameter.Type = []int{}
+			parameter.Description = "Only the records with these ids; repeat the parameter for several."
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+// queryParameters describes the query of the list endpoints of T: filters, sort_by with the
+// SortableFields, and include_deleted for soft-deletable models.
+func queryParameters[T models.Model]() []openapi.Parameter {
+	var model T
+	var orders []string
+	for _, name := range slices.Sorted(maps.Keys(model.SortableFields())) {
+		orders = append(orders, name+":asc", name+":desc")
+	}
+	parameters := append(filterParameters[T](), openapi.Parameter{
+		Name:        "sort_by",
+		In:          "query",
+		Description: "Sort by a field, ascending or descending; repeat the parameter to sort by several.",
+		Type:        []string{},
+		Enum:        orders,
+	})
+	if _, ok := any(model).(models.SoftDeletable); ok {
+		parameters = append(parameters, includeDeletedParameter)
+	}
+	return parameters
+}
+
+var (
+	includeDeletedParameter = openapi.Parameter{
+		Name:        "include_deleted",
+		In:          "query",
+		Description: "Include soft-deleted records. Requires the read_deleted permission.",
+		Type:        false,
+	}
+	batchModeParameter = openapi.Parameter{
+		Name:        "mode",
+		In:          "query",
+		Description: "atomic (the default) rolls the whole batch back when an item fails; partial commits the items that succeed and answers 207.",
+		Type:        "",
+		Enum:        []string{"atomic", "partial"},
+	}
+	idempotencyKeyParameter = openapi.Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "A unique key making the request safe to retry: a repeated request gets the stored response.",
+		Type:        "",
+	}
+	ifMatchParameter = openapi.Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "The ETag of the version being changed, in any format; the request fails with 412 if the record has changed since.",
+		Type:        "",
+	}
+	ifNoneMatchParameter = openapi.Parameter{
+		Name:        "If-None-Match",
+		In:          "header",
+		Description: "An ETag the client already has; the response is 304 if it is still current.",
+		Type:        "",
+	}
+	etagHeader = map[string]string{"ETag": "The version of the record, in the format of the response."}
+)
+
+// operation starts the description of a route of the resource.
+func (res *Resource[T]) operation(summary string) openapi.Operation {
+	return openapi.Operation{Summary: summary, Tags: []string{res.Table}}
+}
+
+// versioned adds the If-Match precondition of writes to versioned models.
+func (res *Resource[T]) versioned(op openapi.Operation) openapi.Operation {
+	if res.hasVersion() {
+		op.Parameters = append(op.Parameters, ifMatchParameter)
+		for i := range op.Responses {
+			if op.Responses[i].Status < 300 {
+				op.Responses[i].Headers = etagHeader
+			}
+		}
+		op.Responses = append(op.Responses, openapi.Response{Status: http.StatusPreconditionFailed, Body: responder.Problem{}, Types: []string{responder.MediaType}})
+	}
+	return op
+}
+
+func (res *Resource[T]) listDoc() openapi.Operation {
+	op := res.operation("List " + res.Table)
+	op.Parameters = queryParameters[T]()
+	op.Parameters = append(op.Parameters, ifNoneMatchParameter)
+	op.Responses = []openapi.Response{
+		{Status: http.StatusOK, Body: listEnvelope[T]{}, Headers: map[string]string{"ETag": "A weak validator of the list."}},
+		{Status: http.StatusNotModified},
+	}
+	return op
+}
+
+func (res *Resource[T]) getDoc() openapi.Operation {
+	op := res.operation("Get " + res.singular() + " by id")
+	if _, ok := res.softDeletable(); ok {
+		op.Parameters = append(op.Parameters, includeDeletedParameter)
+	}
+	op.Parameters = append(op.Parameters, ifNoneMatchParameter)
+	op.Responses = []openapi.Response{{Status: http.StatusOK, Body: dataEnvelope[T]{}}, {Status: http.StatusNotModified}}
+	if res.hasVersion() {
+		op.Responses[0].Headers = etagHeader
+	}
+	return op
+}
+
+func (res *Resource[T]) createDoc() openapi.Operation {
+	op := res.operation("Create " + res.Table)
+	op.Description = "Creates a batch of records in one transaction."
+	op.Parameters = []openapi.Parameter{batchModeParameter, idempotencyKeyParameter}
+	op.Body = []T{}
+	op.Responses = []openapi.Response{
+		{Status: http.StatusCreated, Body: listEnvelope[T]{}},
+		{Status: http.StatusMultiStatus, Description: "The result of every item of a partial batch.", Body: batchResponse{}},
+	}
+	return op
+}
+
+func (res *Resource[T]) replaceDoc() openapi.Operation {
+	op := res.operation("Replace " + res.singular())
+	op.Description = "Replaces the whole record; unknown fields are rejected."
+	op.Parameters = []openapi.Parameter{{
+		Name:        "upsert",
+		In:          "query",
+		Description: "Create the record under the id in the path when it does not exist. Requires the create permission.",
+		Type:        false,
+	}}
+	op.Body = *new(T)
+	op.Responses = []openapi.Response{
+		{Status: http.StatusOK, Body: dataEnvelope[T]{}},
+		{Status: http.StatusCreated, Description: "Created by an upsert.", Body: dataEnvelope[T]{}},
+	}
+	return res.versioned(op)
+}
+
+func (res *Resource[T]) patchManyDoc() openapi.Operation {
+	op := res.operation("Update the " + res.Table + " matching the filters")
+	op.Description = "Sets the fields of the body on every record matching the filters; at least one filter is required."
+	op.Parameters = append(filterParameters[T](), batchModeParameter, idempotencyKeyParameter)
+	op.Body = openapi.Partial[T]{}
+	op.Responses = []openapi.Response{
+		{Status: http.StatusOK, Body: listEnvelope[T]{}},
+		{Status: http.StatusMultiStatus, Description: "The result of every record of a partial batch.", Body: batchResponse{}},
+	}
+	return op
+}
+
+func (res *Resource[T]) patchDoc() openapi.Operation {
+	op := res.operation("Update " + res.singular())
+	op.Description = "The body is the fields to set, a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), chosen by Content-Type."
+	op.Parameters = []openapi.Parameter{idempotencyKeyParameter}
+	op.Bodies = make(map[string]any)
+	for _, mediaType := range codec.MediaTypes() {
+		op.Bodies[mediaType] = openapi.Partial[T]{}
+	}
+	for suffix := range patchSyntaxes {
+		op.Bodies[strings.TrimSuffix(patch.MergePatchMediaType, "json")+suffix] = openapi.Partial[T]{}
+		op.Bodies[strings.TrimSuffix(patch.JSONPatchMediaType, "json")+suffix] = []patch.Operation{}
+	}
+	op.Responses = []openapi.Response{{Status: http.StatusOK, Body: dataEnvelope[T]{}}}
+	return res.versioned(op)
+}
+
+func (res *Resource[T]) deleteManyDoc() openapi.Operation {
+	op := res.operation("Delete the " + res.Table + " matching the filters")
+	op.Description = "At least one filter is required."
+	op.Parameters = filterParameters[T]()
+	op.Responses = []openapi.Response{{Status: http.StatusOK, Body: struct {
+		Status       string `json:"status"`
+		CountDeleted int64  `json:"count_deleted"`
+	}{}}}
+	if _, ok := res.softDeletable(); ok {
+		op.Description += " Records are soft-deleted and can be restored."
+	}
+	return op
+}
+
+func (res *Resource[T]) deleteDoc() openapi.Operation {
+	op := res.operation("Delete " + res.singular())
+	if _, ok := res.softDeletable(); ok {
+		op.Description = "The record is soft-deleted and can be restored."
+	}
+	op.Responses = []openapi.Response{{Status: http.StatusOK, Body: idEnvelope{}}}
+	return res.versioned(op)
+}
+
+func (res *Resource[T]) restoreDoc() openapi.Operation {
+	op := res.operation("Restore deleted " + res.singular())
+	op.Parameters = []openapi.Parameter{idempotencyKeyParameter}
+	op.Responses = []openapi.Response{{Status: http.StatusOK, Body: dataEnvelope[T]{}}}
+	if res.hasVersion() {
+		op.Responses[0].Headers = etagHeader
+	}
+	return op
+}
+
+// exportDoc describes the export of a resource, which takes the filters of its list endpoint.
+func exportDoc[T models.Model](table string) openapi.Operation {
+	parameters := append(queryParameters[T](), openapi.Parameter{
+		Name:        "format",
+		In:          "query",
+		Description: "The file format, taking precedence over Accept. Defaults to csv.",
+		Type:        "",
+		Enum:        slices.Sorted(maps.Keys(exportFormats)),
+	})
+	return openapi.Operation{
+		Summary:     "Export " + table,
+		Description: "Streams the records matching the filters as a file; filters are optional.",
+		Tags:        []string{table},
+		Parameters:  parameters,
+		Responses: []openapi.Response{{
+			Status:  http.StatusOK,
+			Body:    "",
+			Types:   slices.Sorted(maps.Values(exportFormats)),
+			Headers: map[string]string{"Content-Disposition": "The attachment's file name."},
+		}},
+	}
+}
+
+// importDoc describes the import of a spreadsheet into a resource.
+func importDoc(table string) openapi.Operation {
+	upload := struct {
+		File    string `json:"file" validate:"required"`
+		Mapping string `json:"mapping"`
+	}{}
+	return openapi.Operation{
+		Summary: "Import " + table,
+		Description: "Imports a CSV or XLSX file with a header row, sent as the body or as the \"file\" part of a form. " +
+			"Columns map to fields by header name unless a mapping is given.",
+		Tags: []string{table},
+		Parameters: []openapi.Parameter{
+			{Name: "dry_run", In: "query", Description: "Validate the file without importing it.", Type: false},
+			batchModeParameter,
+			{Name: "mapping", In: "query", Description: `A JSON object mapping headers to fields, e.g. {"Given name": "first_name"}.`, Type: ""},
+			idempotencyKeyParameter,
+		},
+		Bodies: map[string]any{"text/csv": "", xlsxMediaType: "", "multipart/form-data": upload},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Body: importResult{}},
+			{Status: http.StatusOK, Description: "The result of a dry run.", Body: importResult{}},
+			{Status: http.StatusMultiStatus, Description: "Some rows of a partial import failed.", Body: importResult{}},
+			{Status: http.StatusUnprocessableEntity, Description: "Some rows failed and nothing was imported.", Body: importResult{}},
+		},
+	}
+}
+
+// oauthTypes are the media types of the OAuth2 endpoints, which follow RFC 6749 rather than pkg/codec.
+var (
+	oauthForm  = []string{"application/x-www-form-urlencoded"}
+	oauthTypes = []string{"application/json"}
+)
+
+// Operations describes the routes registered outside of the resources, by pattern.
+var Operations = map[string]openapi.Operation{
+	"GET /teachers/export":  exportDoc[models.Teacher]("teachers"),
+	"POST /teachers/import": importDoc("teachers"),
+	"GET /students/export":  exportDoc[models.Student]("students"),
+	"POST /students/import": importDoc("students"),
+	"POST /executives/login": {
+		Summary:     "Log in",
+		Description: "Starts a session. Executives with two-factor authentication get an mfa_token to complete at /auth/2fa/verify instead.",
+		Tags:        []string{"auth"},
+		Public:      true,
+		Body:        loginRequest{},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: openapi.AnyOf{sessionResponse{}, struct {
+			Status   string `json:"status"`
+			MFAToken string `json:"mfa_token"`
+		}{}}}},
+	},
+	"DELETE /executives/{id}/sessions": {
+		Summary:     "Revoke the sessions of an executive",
+		Description: "Revokes the refresh tokens of every session; access tokens issued for them are rejected from then on.",
+		Tags:        []string{"executives"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: struct {
+			Status       string `json:"status"`
+			ID           int    `json:"id"`
+			CountRevoked int64  `json:"count_revoked"`
+		}{}}},
+	},
+	"POST /executives/{id}/force-password-change": {
+		Summary:    "Require an executive to change their password",
+		Tags:       []string{"executives"},
+		Parameters: []openapi.Parameter{idempotencyKeyParameter},
+		Responses:  []openapi.Response{{Status: http.StatusOK, Body: idEnvelope{}}},
+	},
+	"POST /executives/{id}/unlock": {
+		Summary:    "Unlock an executive locked out after failed logins",
+		Tags:       []string{"executives"},
+		Parameters: []openapi.Parameter{idempotencyKeyParameter},
+		Responses:  []openapi.Response{{Status: http.StatusOK, Body: idEnvelope{}}},
+	},
+
+	"GET /api-keys/": {
+		Summary:   "List API keys",
+		Tags:      []string{"api_keys"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: listEnvelope[models.APIKey]{}}},
+	},
+	"POST /api-keys/": {
+		Summary: "Create an API key",
+		Tags:    []string{"api_keys"},
+		Body:    createAPIKeyRequest{},
+		Responses: []openapi.Response{{Status: http.StatusCreated, Description: "The key, shown only once.", Body: struct {
+			Status string        `json:"status"`
+			Key    string        `json:"key"`
+			Data   models.APIKey `json:"data"`
+		}{}}},
+	},
+	"DELETE /api-keys/{id}": {
+		Summary:   "Revoke an API key",
+		Tags:      []string{"api_keys"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: idEnvelope{}}},
+	},
+
+	"POST /oauth/token": {
+		Summary:     "Issue a client-credentials token",
+		Description: "OAuth2 client credentials grant (RFC 6749 section 4.4). The client authenticates with HTTP Basic or client_id and client_secret in the form.",
+		Tags:        []string{"oauth"},
+		Public:      true,
+		Body: struct {
+			GrantType    string `json:"grant_type" validate:"required,oneof=client_credentials"`
+			Scope        string `json:"scope"`
+			ClientID     string `json:"client_id"`
+			ClientSecret string `json:"client_secret"`
+		}{},
+		BodyTypes: oauthForm,
+		Responses: []openapi.Response{{Status: http.StatusOK, Types: oauthTypes, Body: struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+			ExpiresIn   int    `json:"expires_in"`
+			Scope       string `json:"scope"`
+		}{}}},
+	},
+	"POST /oauth/introspect": {
+		Summary:     "Introspect a token",
+		Description: "Token introspection (RFC 7662), for authenticated OAuth2 clients.",
+		Tags:        []string{"oauth"},
+		Public:      true,
+		Body: struct {
+			Token string `json:"token" validate:"required"`
+		}{},
+		BodyTypes: oauthForm,
+		Responses: []openapi.Response{{Status: http.StatusOK, Types: oauthTypes, Body: struct {
+			Active    bool   `json:"active"`
+			Scope     string `json:"scope,omitempty"`
+			ClientID  string `json:"client_id,omitempty"`
+			TokenType string `json:"token_type,omitempty"`
+			Exp       int64  `json:"exp,omitempty"`
+			Iat       int64  `json:"iat,omitempty"`
+			Sub       string `json:"sub,omitempty"`
+			Iss       string `json:"iss,omitempty"`
+			Jti       string `json:"jti,omitempty"`
+		}{}}},
+	},
+	"GET /.well-known/jwks.json": {
+		Summary:   "Get the token signing keys",
+		Tags:      []string{"oauth"},
+		Public:    true,
+		Responses: []openapi.Response{{Status: http.StatusOK, Description: "A JSON Web Key Set (RFC 7517).", Types: []string{"application/jwk-set+json"}, Body: map[string]any{}}},
+	},
+	"GET /oauth/clients/": {
+		Summary:   "List OAuth2 clients",
+		Tags:      []string{"oauth"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: listEnvelope[models.OAuthClient]{}}},
+	},
+	"POST /oauth/clients/": {
+		Summary: "Create an OAuth2 client",
+		Tags:    []string{"oauth"},
+		Body: struct {
+			Name   string   `json:"name" validate:"required"`
+			Scopes []string `json:"scopes" validate:"required"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusCreated, Description: "The client, with its secret shown only once.", Body: struct {
+			Status       string             `json:"status"`
+			ClientSecret string             `json:"client_secret"`
+			Data         models.OAuthClient `json:"data"`
+		}{}}},
+	},
+	"DELETE /oauth/clients/{id}": {
+		Summary:   "Revoke an OAuth2 client",
+		Tags:      []string{"oauth"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: idEnvelope{}}},
+	},
+
+	"GET /audit": {
+		Summary: "List audit log entries",
+		Tags:    []string{"audit"},
+		Parameters: []openapi.Parameter{
+			{Name: "actor", In: "query", Type: ""},
+			{Name: "action", In: "query", Type: ""},
+			{Name: "resource", In: "query", Type: ""},
+			{Name: "record_id", In: "query", Type: ""},
+			{Name: "from", In: "query", Description: "Entries at or after this time.", Type: time.Time{}},
+			{Name: "to", In: "query", Description: "Entries before this time.", Type: time.Time{}},
+			{Name: "limit", In: "query", Description: "At most 1000; defaults to 100.", Type: 0},
+			{Name: "offset", In: "query", Type: 0},
+		},
+		Responses: []openapi.Response{{Status: http.StatusOK, Description: "The entries, newest first.", Body: listEnvelope[models.AuditEntry]{}}},
+	},
+
+	"GET /integrations/whoami": {
+		Summary:  "Describe the caller",
+		Tags:     []string{"integrations"},
+		Security: []string{"mutualTLS"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: struct {
+			Status string   `json:"status"`
+			Kind   string   `json:"kind"`
+			Name   string   `json:"name"`
+			Role   string   `json:"role,omitempty"`
+			Scopes []string `json:"scopes,omitempty"`
+		}{}}},
+	},
+
+	"POST /auth/refresh": {
+		Summary:     "Refresh a session",
+		Description: "Exchanges a refresh token for a new access token and a rotated refresh token.",
+		Tags:        []string{"auth"},
+		Public:      true,
+		Body:        refreshRequest{},
+		Responses:   []openapi.Response{{Status: http.StatusOK, Body: sessionResponse{}}},
+	},
+	"POST /auth/logout": {
+		Summary:   "Log out",
+		Tags:      []string{"auth"},
+		Public:    true,
+		Body:      refreshRequest{},
+		Responses: []openapi.Response{{Status: http.StatusNoContent}},
+	},
+	"POST /auth/forgot-password": {
+		Summary: "Request a password reset",
+		Tags:    []string{"auth"},
+		Public:  true,
+		Body: struct {
+			Email string `json:"email" validate:"required,email"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusAccepted, Body: struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}{}}},
+	},
+	"POST /auth/reset-password": {
+		Summary: "Reset a password",
+		Tags:    []string{"auth"},
+		Public:  true,
+		Body: struct {
+			Token       string `json:"token" validate:"required"`
+			NewPassword string `json:"new_password" validate:"required"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusNoContent}},
+	},
+	"POST /auth/change-password": {
+		Summary:  "Change the caller's password",
+		Tags:     []string{"auth"},
+		Security: []string{"bearerAuth"},
+		Body: struct {
+			CurrentPassword string `json:"current_password" validate:"required"`
+			NewPassword     string `json:"new_password" validate:"required"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusNoContent}},
+	},
+	"POST /auth/2fa/enroll": {
+		Summary:  "Start two-factor enrollment",
+		Tags:     []string{"auth"},
+		Security: []string{"bearerAuth"},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: struct {
+			Status string `json:"status"`
+			Secret string `json:"secret"`
+			URI    string `json:"otpauth_uri"`
+		}{}}},
+	},
+	"POST /auth/2fa/confirm": {
+		Summary:  "Confirm two-factor enrollment",
+		Tags:     []string{"auth"},
+		Security: []string{"bearerAuth"},
+		Body: struct {
+			Code string `json:"code" validate:"required"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusOK, Description: "The recovery codes, shown only once.", Body: struct {
+			Status        string   `json:"status"`
+			RecoveryCodes []string `json:"recovery_codes"`
+		}{}}},
+	},
+	"POST /auth/2fa/verify": {
+		Summary:     "Complete a two-factor login",
+		Description: "Exchanges the mfa_token of a login and a TOTP or recovery code for a session.",
+		Tags:        []string{"auth"},
+		Public:      true,
+		Body: struct {
+			MFAToken     string `json:"mfa_token" validate:"required"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}{},
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: sessionResponse{}}},
+	},
+}
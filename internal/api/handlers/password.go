@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/pkg/notifier"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+const minPasswordLength = 12
+
+// passwordNotifier delivers password reset tokens. It defaults to logging and is replaced at startup.
+var passwordNotifier notifier.Notifier = notifier.LogNotifier{}
+
+// SetNotifier sets the notifier used to deliver password reset tokens.
+func SetNotifier(n notifier.Notifier) {
+	passwordNotifier = n
+}
+
+// passwordResetTTL reads PASSWORD_RESET_EXPIRES_IN (e.g. "30m"), defaulting to 30 minutes.
+func passwordResetTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_EXPIRES_IN"))
+	if err != nil || ttl <= 0 {
+		return 30 * time.Minute
+	}
+	return ttl
+}
+
+// validateNewPassword enforces the minimum password policy.
+func validateNewPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	return nil
+}
+
+// ForgotPasswordHandler issues a single-use, expiring reset token and delivers it through the notifier.
+// It answers 202 before looking the account up, whether or not it exists and whatever happens next,
+// so neither the status nor the response time tells callers which e-mail addresses are registered.
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Email == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	go sendPasswordReset(context.WithoutCancel(r.Context()), req.Email)
+
+	response := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		Status:  "success",
+		Message: "If the account exists, a password reset token has been sent",
+	}
+	writeResponse(w, r, http.StatusAccepted, response)
+}
+
+// sendPasswordReset stores a reset token for the executive with the given e-mail address, if any, and
+// sends it. It runs after ForgotPasswordHandler has answered, so failures are only logged.
+func sendPasswordReset(ctx context.Context, email string) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		return
+	}
+	defer db.Close()
+
+	// Errors are logged by sqlconnect
+	exec, err := sqlconnect.GetExecutiveByEmail(ctx, db, email)
+	if err != nil {
+		return
+	}
+
+	token, hash, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		log.Printf("Error generating reset token: %v", err)
+		return
+	}
+
+	expiresAt := time.Now().Add(passwordResetTTL())
+	if err := sqlconnect.CreatePasswordResetToken(ctx, db, exec.ID, hash, expiresAt); err != nil {
+		return
+	}
+
+	msg := notifier.Message{
+		To:      exec.Email,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Use this token with POST /auth/reset-password before %s: %s", expiresAt.UTC().Format(time.RFC3339), token),
+	}
+	if err := passwordNotifier.Send(ctx, msg); err != nil {
+		log.Printf("Error sending password reset to executive %d: %v", exec.ID, err)
+	}
+}
+
+// ResetPasswordHandler sets a new password using a reset token. The token is consumed,
+// any forced change is cleared and every existing session of the executive is revoked.
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Token == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		responder.Error(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		responder.Error(w, r, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	executiveID, err := sqlconnect.ConsumePasswordResetToken(r.Context(), tx, utils.HashToken(req.Token))
+	if err == sqlconnect.ErrResetTokenInvalid {
+		responder.Error(w, r, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error consuming reset token: %v", err)
+		responder.Error(w, r, "Error consuming reset token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := sqlconnect.ChangeExecutivePassword(r.Context(), tx, executiveID, hash); err != nil {
+		responder.Error(w, r, "Error updating password", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := sqlconnect.RevokeExecutiveRefreshTokens(r.Context(), db, executiveID, ""); err != nil {
+		log.Printf("Password reset for executive %d but sessions were not revoked", executiveID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePasswordHandler lets an authenticated executive change their own password.
+// It is the only route available while a forced password change is pending. Wrong current
+// passwords count towards the login lockout, and every other session of the executive is revoked.
+func ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := executiveFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.CurrentPassword == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		responder.Error(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		responder.Error(w, r, "New password must differ from the current password", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	exec, err := sqlconnect.GetExecutiveByID(r.Context(), db, principal.ID)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "Executive not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+
+	username := normalizeUsername(exec.Username)
+	if rejectIfLocked(w, r, db, username) {
+		return
+	}
+	if ok, err := utils.VerifyPassword(req.CurrentPassword, exec.Password); err != nil || !ok {
+		registerLoginFailure(r, db, username)
+		responder.Error(w, r, "Current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+	if _, err := sqlconnect.ClearLoginFailures(r.Context(), db, username); err != nil {
+		log.Printf("Could not reset login failures for %s", username)
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		responder.Error(w, r, "Error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := sqlconnect.ChangeExecutivePassword(r.Context(), tx, exec.ID, hash); err != nil {
+		responder.Error(w, r, "Error updating password", http.StatusInternalServerError)
+		return
+	}
+	if _, err := sqlconnect.RevokeExecutiveRefreshTokens(r.Context(), tx, exec.ID, principal.Session); err != nil {
+		responder.Error(w, r, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForcePasswordChangeHandler flags an executive so every API call is blocked until they change their password.
+func ForcePasswordChangeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 0 {
+		responder.Error(w, r, "Invalid executive id", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	rowsAffected, err := sqlconnect.SetMustChangePassword(r.Context(), db, id, true)
+	if err != nil {
+		responder.Error(w, r, "Error updating executive", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		// MySQL reports 0 when the flag was already set, so confirm the executive exists
+		if _, err := sqlconnect.GetExecutiveByID(r.Context(), db, id); err == sql.ErrNoRows {
+			responder.Error(w, r, "Executive not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	// Authorized routes read the flag from the database, so current access tokens are blocked
+	// at once; revoking the sessions keeps them from being refreshed
+	if _, err := sqlconnect.RevokeExecutiveRefreshTokens(r.Context(), db, id, ""); err != nil {
+		responder.Error(w, r, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		ID     int    `json:"id"`
+	}{
+		Status: "success",
+		ID:     id,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/patch"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// patchRequest is the body of a single-record PATCH, interpreted according to its Content-Type:
+//
+//	application/json               top-level fields to set
+//	application/merge-patch+json   RFC 7396 merge patch; null clears a field
+//	application/json-patch+json    RFC 6902 operations, applied in order; a failing "test" aborts the update
+//
+// The other request formats of pkg/codec (XML, MessagePack, CBOR) carry top-level fields like
+// application/json, and the patch formats may be written in them too with the matching structured
+// syntax suffix, e.g. application/merge-patch+xml or application/json-patch+cbor. XML is read against
+// the model's field types: fields and merge patches against the model, the value of each JSON Patch
+// operation against the field its path points to.
+// Whatever the format, the fields it changes are validated like any other update (see validation.go).
+type patchRequest struct {
+	mediaType string
+	fields    map[string]any
+	ops       []patch.Operation
+}
+
+// patchSyntaxes maps the structured syntax suffixes of the patch media types to their codec.
+var patchSyntaxes = map[string]codec.Codec{"json": codec.JSON, "xml": codec.XML, "msgpack": codec.MessagePack, "cbor": codec.CBOR}
+
+// decodePatchRequest reads a single-record PATCH body of a model, a pointer to a zero value of the
+// record type. It returns the HTTP status to answer with on error.
+func decodePatchRequest(r *http.Request, model any) (patchRequest, int, error) {
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return patchRequest{}, http.StatusUnsupportedMediaType, errors.New("Invalid Content-Type")
+		}
+	}
+
+	req := patchRequest{mediaType: "application/json"}
+	c, ok := codec.ForContentType(mediaType)
+	if base, suffix, found := strings.Cut(mediaType, "+"); found && (base+"+json" == patch.MergePatchMediaType || base+"+json" == patch.JSONPatchMediaType) {
+		req.mediaType = base + "+json"
+		c, ok = patchSyntaxes[suffix]
+	}
+	if !ok {
+		return patchRequest{}, http.StatusUnsupportedMediaType,
+			fmt.Errorf("Unsupported Content-Type %q: use %s, %s or %s", mediaType, strings.Join(codec.MediaTypes(), ", "), patch.MergePatchMediaType, patch.JSONPatchMediaType)
+	}
+
+	var err error
+	if req.mediaType == patch.JSONPatchMediaType {
+		req.ops, err = decodeOperations(r.Body, c, model)
+	} else {
+		var body io.Reader
+		if body, err = c.Transcode(r.Body, model); err == nil {
+			err = json.NewDecoder(body).Decode(&req.fields)
+		}
+	}
+	if err != nil {
+		log.Printf("Invalid request payload: %v", err)
+		return patchRequest{}, http.StatusBadRequest, errors.New("Invalid request payload")
+	}
+	return req, http.StatusOK, nil
+}
+
+// decodeOperations reads JSON Patch operations written with c. The value of each operation is
+// transcoded as the type of the field of model its path points to, so a first pass reads the paths.
+func decodeOperations(body io.Reader, c codec.Codec, model any) ([]patch.Operation, error) {
+	var ops []patch.Operation
+	if c == codec.JSON {
+		err := json.NewDecoder(body).Decode(&ops)
+		return ops, err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	untyped, err := c.Transcode(bytes.NewReader(data), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(untyped).Decode(&ops); err != nil {
+		return nil, err
+	}
+
+	items := make(codec.Items, len(ops))
+	for i, op := range ops {
+		t := fieldTypeAt(reflect.TypeOf(model), op.Path)
+		if t == nil {
+			continue
+		}
+		items[i] = reflect.New(reflect.StructOf([]reflect.StructField{
+			{Name: "Op", Type: reflect.TypeFor[string](), Tag: `json:"op"`},
+			{Name: "Path", Type: reflect.TypeFor[string](), Tag: `json:"path"`},
+			{Name: "From", Type: reflect.TypeFor[string](), Tag: `json:"from"`},
+			{Name: "Value", Type: t, Tag: `json:"value"`},
+		})).Interface()
+	}
+	typed, err := c.Transcode(bytes.NewReader(data), items)
+	if err != nil {
+		return nil, err
+	}
+	ops = nil
+	err = json.NewDecoder(typed).Decode(&ops)
+	return ops, err
+}
+
+// fieldTypeAt returns the type of the value a JSON Pointer designates in a value of type t, nil when
+// the pointer leaves the fields of t.
+func fieldTypeAt(t reflect.Type, pointer string) reflect.Type {
+	if pointer == "" {
+		return t
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil
+	}
+	for token := range strings.SplitSeq(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(t, token)
+			if !ok {
+				return nil
+			}
+			t = field
+		case reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return nil
+		}
+	}
+	return t
+}
+
+// jsonField returns the type of the field of struct type t called name in JSON, looking into
+// embedded structs like encoding/json.
+func jsonField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		field, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if sf.Anonymous && field == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if ft, ok := jsonField(embedded, name); ok {
+					return ft, true
+				}
+				continue
+			}
+		}
+		if field == "" {
+			field = sf.Name
+		}
+		if sf.IsExported() && field == name {
+			return sf.Type, true
+		}
+	}
+	return nil, false
+}
+
+// errPatchTarget marks patch documents that do not leave a record behind.
+var errPatchTarget = errors.New("patch cannot be applied")
+
+// updates resolves the request against the current record into the fields to change, keyed by JSON
+// name. They still have to be validated against the model with validateUpdates.
+func (p patchRequest) updates(current any) (map[string]any, error) {
+	if p.mediaType == "application/json" {
+		return p.fields, nil
+	}
+	return p.changedFields(current)
+}
+
+// updateClauses turns validated updates into the SET clauses and arguments of an UPDATE, in field
+// order. columns maps JSON fields to columns.
+func updateClauses(updates map[string]any, columns map[string]string) ([]string, []any) {
+	names := make([]string, 0, len(updates))
+	for name := range updates {
+		if _, ok := columns[name]; ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	clauses := make([]string, 0, len(names))
+	args := make([]any, 0, len(names))
+	for _, name := range names {
+		clauses = append(clauses, fmt.Sprintf("%s = ?", columns[name]))
+		args = append(args, updates[name])
+	}
+	return clauses, args
+}
+
+// changedFields applies a patch document to the columns of current and returns the top-level
+// fields whose value changed, with nil for removed fields. Empty and zero columns are part of the
+// document, so paths to them resolve although the models omit them from JSON.
+func (p patchRequest) changedFields(current any) (map[string]any, error) {
+	before, err := recordFields(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if p.mediaType == patch.MergePatchMediaType {
+		result = patch.Merge(before, p.fields)
+	} else if result, err = patch.Apply(before, p.ops); err != nil {
+		return nil, err
+	}
+	after, ok := result.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: the document root must remain an object", errPatchTarget)
+	}
+
+	changed := make(map[string]any)
+	for name, value := range after {
+		if !reflect.DeepEqual(before[name], value) {
+			changed[name] = value
+		}
+	}
+	for name, value := range before {
+		if _, ok := after[name]; !ok && value != nil {
+			changed[name] = nil
+		}
+	}
+	return changed, nil
+}
+
+// writePatchError answers a patch that could not be applied: 409 when a "test" operation failed,
+// 422 when the document is valid JSON but cannot be applied to the record.
+func writePatchError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, patch.ErrTestFailed) {
+		responder.Error(w, r, "Patch test failed: "+err.Error(), http.StatusConflict)
+		return
+	}
+	responder.Error(w, r, "Patch cannot be applied: "+err.Error(), http.StatusUnprocessableEntity)
+}
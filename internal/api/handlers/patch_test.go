@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/patch"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// TestPatchUpdates resolves PATCH bodies against a classroom with empty and zero fields, which the
+// JSON of the model omits, and validates the result like PatchOne does.
+func TestPatchUpdates(t *testing.T) {
+	current := models.Classroom{ID: 4, RoomNumber: "101", Version: 2}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        map[string]any // the validated updates
+		wantErr     error          // from resolving the patch
+		wantInvalid []string       // fields failing validation
+	}{
+		{
+			name:        "replace an empty field",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "replace", "path": "/building", "value": "North"}]`,
+			want:        map[string]any{"building": "North"},
+		},
+		{
+			name:        "test a zero field",
+			contentType: patch.JSONPatchMediaType,
+			body: `[
+				{"op": "test", "path": "/capacity", "value": 0},
+				{"op": "replace", "path": "/capacity", "value": 30}
+			]`,
+			want: map[string]any{"capacity": 30},
+		},
+		{
+			name:        "test an empty field",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "test", "path": "/building", "value": ""}, {"op": "add", "path": "/building", "value": "South"}]`,
+			want:        map[string]any{"building": "South"},
+		},
+		{
+			name:        "failed test",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "test", "path": "/capacity", "value": 10}, {"op": "replace", "path": "/capacity", "value": 30}]`,
+			wantErr:     patch.ErrTestFailed,
+		},
+		{
+			name:        "unchanged value",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "replace", "path": "/room_number", "value": "101"}]`,
+			want:        map[string]any{},
+		},
+		{
+			name:        "remove a set field",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "remove", "path": "/room_number"}]`,
+			wantInvalid: []string{"room_number"},
+		},
+		{
+			name:        "readonly field",
+			contentType: patch.JSONPatchMediaType,
+			body:        `[{"op": "replace", "path": "/version", "value": 9}]`,
+			wantInvalid: []string{"version"},
+		},
+		{
+			name:        "merge patch null on an optional field",
+			contentType: patch.MergePatchMediaType,
+			body:        `{"building": null, "capacity": 25}`,
+			want:        map[string]any{"building": nil, "capacity": 25},
+		},
+		{
+			name:        "merge patch null on a required field",
+			contentType: patch.MergePatchMediaType,
+			body:        `{"room_number": null}`,
+			wantInvalid: []string{"room_number"},
+		},
+		{
+			name:        "merge patch of an empty field",
+			contentType: patch.MergePatchMediaType,
+			body:        `{"building": "East"}`,
+			want:        map[string]any{"building": "East"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/classrooms/4", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			req, _, err := decodePatchRequest(r, new(models.Classroom))
+			if err != nil {
+				t.Fatalf("decodePatchRequest() error = %v", err)
+			}
+
+			updates, err := req.updates(current)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("updates() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("updates() error = %v", err)
+			}
+
+			errs, err := validator.Validator{}.Fields(context.Background(), models.Classroom{}, updates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var invalid []string
+			for _, fieldErr := range errs {
+				invalid = append(invalid, fieldErr.Field)
+			}
+			if !reflect.DeepEqual(invalid, tt.wantInvalid) {
+				t.Errorf("invalid fields = %v, want %v", invalid, tt.wantInvalid)
+			}
+			if len(invalid) == 0 && !reflect.DeepEqual(updates, tt.want) {
+				t.Errorf("updates = %#v, want %#v", updates, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// PUT replaces a whole record: it must pass validation as a complete record and unknown fields are rejected.
+// Sync tools can opt into create-or-replace with ?upsert=true, which creates the record under the
+// id in the path when it does not exist and additionally requires the "<resource>:create" permission.
+
+// upsertRequested reports whether a PUT opted into upsert. Callers without create permission get a 403 and ok is false.
+func upsertRequested(w http.ResponseWriter, r *http.Request, resource string) (upsert bool, ok bool) {
+	if r.URL.Query().Get("upsert") != "true" {
+		return false, true
+	}
+
+	principal, _ := utils.PrincipalFromContext(r.Context())
+	if !middlewares.Can(principal, resource, "create") {
+		responder.Error(w, r, "Forbidden: upsert requires permission "+resource+":create", http.StatusForbidden)
+		return false, false
+	}
+	return true, true
+}
+
+// decodeReplacement strictly decodes the full record sent with a PUT into dst, answering 415 or 400
+// itself. It reports whether dst was decoded.
+func decodeReplacement(w http.ResponseWriter, r *http.Request, dst any) bool {
+	decoder, ok := bodyDecoder(w, r, dst)
+	if !ok {
+		return false
+	}
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		log.Printf("Invalid request payload: %v", err)
+		responder.Error(w, r, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if decoder.More() {
+		responder.Error(w, r, "Invalid request payload: expected a single JSON object", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// checkReplacementID rejects a PUT body whose id differs from the id in the path, writing a 400.
+// The rest of the record is checked with validateRecord.
+func checkReplacementID(w http.ResponseWriter, r *http.Request, pathID, bodyID int) bool {
+	if bodyID != 0 && bodyID != pathID {
+		responder.Error(w, r, "Body id does not match the id in the path", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,1013 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/dbmap"
+	"github.com/jorge-sader/go-rest-api/pkg/openapi"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// Resource serves the CRUD routes of a model stored in a table of its own: list, get, batch create,
+// PUT, PATCH one or many and DELETE one or many, plus restore for soft-deletable models.
+// What a model supports is read from the interfaces it implements:
+//
+//	models.Model          filters and sorting (required)
+//	models.SoftDeletable  DELETE sets deleted_at, deleted rows are hidden and can be restored
+//	models.Versioned      every write increments version, exposed as the ETag and checked against If-Match
+//
+// Columns are the fields of T with a db tag (see pkg/dbmap), the primary key being "id".
+// Fields tagged validate:"readonly" (ids, timestamps and versions) are read but never written.
+// Creates and updates are validated, audited and run in one transaction like any other write.
+type Resource[T models.Model] struct {
+	// Table is the table, the first segment of the routes and the RBAC resource, e.g. "classrooms".
+	Table string
+	// Singular names one record in messages, e.g. "Classroom".
+	Singular string
+}
+
+// Route is a route served by a Resource. Action overrides the RBAC action implied by the method.
+// Doc describes the route in the OpenAPI document.
+type Route struct {
+	Pattern string
+	Action  string
+	Handler http.HandlerFunc
+	Doc     openapi.Operation
+}
+
+// Name returns the RBAC resource of the routes.
+func (res *Resource[T]) Name() string {
+	return res.Table
+}
+
+// Routes lists the routes of the resource under /<Table>/.
+func (res *Resource[T]) Routes() []Route {
+	base := "/" + res.Table + "/"
+	routes := []Route{
+		{Pattern: "GET " + base, Handler: res.GetMany, Doc: res.listDoc()},
+		{Pattern: "GET " + base + "{id}", Handler: res.GetOne, Doc: res.getDoc()},
+		{Pattern: "POST " + base, Handler: res.AddMany, Doc: res.createDoc()},
+		{Pattern: "PUT " + base + "{id}", Handler: res.PutOne, Doc: res.replaceDoc()},
+		{Pattern: "PATCH " + base, Handler: res.PatchMany, Doc: res.patchManyDoc()},
+		{Pattern: "PATCH " + base + "{id}", Handler: res.PatchOne, Doc: res.patchDoc()},
+		{Pattern: "DELETE " + base, Handler: res.DeleteMany, Doc: res.deleteManyDoc()},
+		{Pattern: "DELETE " + base + "{id}", Handler: res.DeleteOne, Doc: res.deleteDoc()},
+	}
+	if _, ok := res.softDeletable(); ok {
+		routes = append(routes, Route{Pattern: "POST " + base + "{id}/restore", Action: "restore", Handler: res.Restore, Doc: res.restoreDoc()})
+	}
+	return routes
+}
+
+// GetMany retrieves the records matching the filters, sorted as requested.
+func (res *Resource[T]) GetMany(w http.ResponseWriter, r *http.Request) {
+	GetManyHandler[T](w, r, res.Table)
+}
+
+// GetOne retrieves a single record by ID.
+func (res *Resource[T]) GetOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := res.pathID(w, r)
+	if !ok {
+		return
+	}
+
+	withDeleted, ok := includeDeleted(w, r, res.Table)
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	query := res.selectQuery() + ` WHERE id = ?`
+	if !withDeleted {
+		query += res.notDeleted()
+	}
+	var item T
+	err = dbmap.Scan(db.QueryRowContext(r.Context(), query, id), &item)
+	if err == sql.ErrNoRows {
+		log.Printf("%s not found: id=%d", res.Singular, id)
+		responder.Error(w, r, res.Singular+" not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error querying %s: %v", res.Table, err)
+		responder.Error(w, r, "Error querying "+res.singular(), http.StatusInternalServerError)
+		return
+	}
+
+	if etag, ok := res.etag(r, item); ok && notModified(w, r, etag) {
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Data   T      `json:"data"`
+	}{
+		Status: "success",
+		Data:   item,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// AddMany creates multiple records with transaction support.
+// The batch is all-or-nothing unless ?mode=partial asks for per-item results; see batch.go.
+func (res *Resource[T]) AddMany(w http.ResponseWriter, r *http.Request) {
+	partial, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	var newItems []T
+	if !decodeBody(w, r, &newItems) {
+		return
+	}
+
+	if len(newItems) == 0 {
+		log.Printf("Empty %s list", res.singular())
+		responder.Error(w, r, "Empty "+res.singular()+" list", http.StatusBadRequest)
+		return
+	}
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(r.Context(), res.insertQuery())
+	if err != nil {
+		log.Printf("Error preparing SQL statement: %v", err)
+		responder.Error(w, r, "Error preparing SQL statement", http.StatusInternalServerError)
+		return
+	}
+	defer stmt.Close()
+
+	validate := payloadValidator(tx)
+	if partial {
+		runPartialBatch(w, r, tx, len(newItems), func(i int) batchResult {
+			if err := validateItem(r, validate, newItems[i]); err != nil {
+				return failedItem(i, err)
+			}
+			added, err := res.insert(r, tx, stmt, newItems[i])
+			if err != nil {
+				return failedItem(i, err)
+			}
+			return batchResult{Index: i, Status: http.StatusCreated, ID: res.id(&added), Data: added}
+		})
+		return
+	}
+
+	// Validate every record before inserting any, reporting all errors at once
+	var invalid validator.Errors
+	for i, newItem := range newItems {
+		errs, err := validate.Struct(r.Context(), newItem)
+		if err != nil {
+			log.Printf("Error validating %s: %v", res.singular(), err)
+			responder.Error(w, r, "Error validating payload", http.StatusInternalServerError)
+			return
+		}
+		invalid = append(invalid, errs.Prefixed(fmt.Sprintf("[%d].", i))...)
+	}
+	if len(invalid) > 0 {
+		responder.ValidationFailed(w, r, invalid)
+		return
+	}
+
+	addedItems := make([]T, 0, len(newItems))
+	for _, newItem := range newItems {
+		added, err := res.insert(r, tx, stmt, newItem)
+		if err != nil {
+			writeItemError(w, r, err)
+			return
+		}
+		addedItems = append(addedItems, added)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+		Data   []T    `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(addedItems),
+		Data:   addedItems,
+	}
+	writeResponse(w, r, http.StatusCreated, response)
+}
+
+// insert inserts a validated record through stmt, a prepared insertQuery, and records it in the audit log.
+// It returns the record as stored, with its id and the defaults filled in by the database.
+func (res *Resource[T]) insert(r *http.Request, tx *sql.Tx, stmt *sql.Stmt, newItem T) (T, error) {
+	var added T
+	result, err := stmt.ExecContext(r.Context(), columnValues(&newItem, res.writableColumns())...)
+	if err != nil {
+		log.Printf("Error executing SQL statement: %v", err)
+		if message, ok := sqlconnect.ConstraintViolation(err); ok {
+			return added, &itemError{http.StatusUnprocessableEntity, "Invalid field value: " + message}
+		}
+		return added, &itemError{http.StatusInternalServerError, "Error executing SQL statement"}
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("Error retrieving last inserted ID: %v", err)
+		return added, &itemError{http.StatusInternalServerError, "Error retrieving last inserted ID"}
+	}
+
+	id := int(lastID)
+	if err := dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`, id), &added); err != nil {
+		log.Printf("Error querying inserted %s ID %d: %v", res.singular(), id, err)
+		return added, &itemError{http.StatusInternalServerError, "Error querying inserted " + res.Table}
+	}
+	if err := recordMutation(r, tx, auditCreate, res.Table, id, nil, added); err != nil {
+		return added, &itemError{http.StatusInternalServerError, "Error writing audit log"}
+	}
+	return added, nil
+}
+
+// PutOne replaces a single record by ID (full update) with transaction support.
+// With ?upsert=true a missing record is created under the id in the path; see put.go.
+func (res *Resource[T]) PutOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := res.pathID(w, r)
+	if !ok {
+		return
+	}
+
+	upsert, ok := upsertRequested(w, r, res.Table)
+	if !ok {
+		return
+	}
+
+	var replacement T
+	if !decodeReplacement(w, r, &replacement) {
+		return
+	}
+	if !checkReplacementID(w, r, id, res.id(&replacement)) {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	// Uses defer tx.Rollback() to ensure transaction cleanup on errors,
+	// with tx.Commit() called only on success. The rollback after a commit fails silently, which is safe.
+	defer tx.Rollback()
+
+	// Soft-deleted rows are read too: their id cannot be reused by an upsert
+	var existing T
+	err = dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ? FOR UPDATE`, id), &existing)
+	created := err == sql.ErrNoRows && upsert
+	switch {
+	case created:
+		// If-Match can never match a record that does not exist
+		if r.Header.Get("If-Match") != "" {
+			responder.Error(w, r, "Precondition failed: "+res.singular()+" does not exist", http.StatusPreconditionFailed)
+			return
+		}
+	case err == sql.ErrNoRows:
+		responder.Error(w, r, res.Singular+" not found", http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Error querying %s: %v", res.singular(), err)
+		responder.Error(w, r, "Error querying "+res.singular(), http.StatusInternalServerError)
+		return
+	case res.deleted(&existing) && upsert:
+		responder.Error(w, r, res.Singular+" was deleted: restore it before replacing it", http.StatusConflict)
+		return
+	case res.deleted(&existing):
+		responder.Error(w, r, res.Singular+" not found", http.StatusNotFound)
+		return
+	default:
+		if !res.checkIfMatch(w, r, existing) {
+			return
+		}
+	}
+
+	if !validateRecord(w, r, tx, replacement) {
+		return
+	}
+
+	columns := res.writableColumns()
+	values := columnValues(&replacement, columns)
+	if created {
+		_, err = tx.ExecContext(r.Context(), fmt.Sprintf(`INSERT INTO %s (id, %s) VALUES (?%s)`, res.Table,
+			strings.Join(dbmap.Names(columns), ", "), strings.Repeat(", ?", len(columns))), append([]any{id}, values...)...)
+	} else {
+		setClauses := make([]string, len(columns))
+		for i, column := range columns {
+			setClauses[i] = column.Name + " = ?"
+		}
+		_, err = tx.ExecContext(r.Context(), fmt.Sprintf(`UPDATE %s SET %s%s WHERE id = ?`, res.Table,
+			strings.Join(setClauses, ", "), res.versionIncrement()), append(values, id)...)
+	}
+	if err != nil {
+		log.Printf("Error replacing %s ID %d: %v", res.singular(), id, err)
+		if message, ok := sqlconnect.ConstraintViolation(err); ok {
+			responder.Error(w, r, "Invalid field value: "+message, http.StatusUnprocessableEntity)
+			return
+		}
+		responder.Error(w, r, "Error replacing "+res.singular(), http.StatusInternalServerError)
+		return
+	}
+
+	var replaced T
+	if err := dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`, id), &replaced); err != nil {
+		log.Printf("Error querying replaced %s: %v", res.singular(), err)
+		responder.Error(w, r, "Error querying replaced "+res.singular(), http.StatusInternalServerError)
+		return
+	}
+
+	if created {
+		err = recordMutation(r, tx, auditCreate, res.Table, id, nil, replaced)
+	} else {
+		err = recordMutation(r, tx, auditUpdate, res.Table, id, existing, replaced)
+	}
+	if err != nil {
+		responder.Error(w, r, "Error writing audit log", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		w.Header().Set("Location", fmt.Sprintf("/%s/%d", res.Table, id))
+	}
+	if etag, ok := res.etag(r, replaced); ok {
+		w.Header().Set("ETag", etag)
+	}
+	response := struct {
+		Status string `json:"status"`
+		Data   T      `json:"data"`
+	}{
+		Status: "success",
+		Data:   replaced,
+	}
+	writeResponse(w, r, status, response)
+}
+
+// PatchOne partially updates a single record by ID with dynamic UPDATE query.
+// The body is a set of fields (application/json), a JSON Merge Patch (application/merge-patch+json)
+// or a JSON Patch (application/json-patch+json), selected by Content-Type; see patchRequest.
+func (res *Resource[T]) PatchOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := res.pathID(w, r)
+	if !ok {
+		return
+	}
+
+	req, status, err := decodePatchRequest(r, new(T))
+	if err != nil {
+		responder.Error(w, r, err.Error(), status)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Verify the record exists
+	var existing T
+	err = dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`+res.notDeleted()+` FOR UPDATE`, id), &existing)
+	if err == sql.ErrNoRows {
+		log.Printf("%s not found: id=%d", res.Singular, id)
+		responder.Error(w, r, res.Singular+" not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error querying %s: %v", res.singular(), err)
+		responder.Error(w, r, "Error querying "+res.singular(), http.StatusInternalServerError)
+		return
+	}
+	if !res.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	// Resolve the request against the current record, validate the changes and build dynamic SET clause
+	updates, err := req.updates(existing)
+	if err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+	var model T
+	if !validateUpdates(w, r, tx, model, updates) {
+		return
+	}
+	setClauses, args := updateClauses(updates, res.fieldColumns())
+	if len(setClauses) == 0 {
+		log.Printf("No valid fields to update for %s ID %d", res.singular(), id)
+		responder.Error(w, r, "No valid fields to update", http.StatusBadRequest)
+		return
+	}
+
+	// Build and execute dynamic UPDATE query
+	query := fmt.Sprintf(`UPDATE %s SET %s%s WHERE id = ?`, res.Table, strings.Join(setClauses, ", "), res.versionIncrement())
+	updated, err := res.update(r, tx, query, args, existing)
+	if err != nil {
+		writeItemError(w, r, err)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if etag, ok := res.etag(r, updated); ok {
+		w.Header().Set("ETag", etag)
+	}
+	response := struct {
+		Status string `json:"status"`
+		Data   T      `json:"data"`
+	}{
+		Status: "success",
+		Data:   updated,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// PatchMany partially updates the records matching the filters with dynamic UPDATE query.
+// With ?mode=partial each matching record is updated independently; see batch.go.
+func (res *Resource[T]) PatchMany(w http.ResponseWriter, r *http.Request) {
+	partial, ok := batchMode(w, r)
+	if !ok {
+		return
+	}
+
+	updates, ok := decodeFields(w, r, new(T))
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Validate and build dynamic SET clause
+	var model T
+	if !validateUpdates(w, r, tx, model, updates) {
+		return
+	}
+	setClauses, args := updateClauses(updates, res.fieldColumns())
+	if len(setClauses) == 0 {
+		log.Printf("No valid fields to update")
+		responder.Error(w, r, "No valid fields to update", http.StatusBadRequest)
+		return
+	}
+
+	items, ok := res.selectFiltered(w, r, tx)
+	if !ok {
+		return
+	}
+	if len(items) == 0 {
+		log.Printf("No %s found to update", res.Table)
+		responder.Error(w, r, "No "+res.Table+" found to update", http.StatusNotFound)
+		return
+	}
+
+	// Build and execute dynamic UPDATE query for all matching records
+	updateQuery := fmt.Sprintf(`UPDATE %s SET %s%s WHERE id = ?`, res.Table, strings.Join(setClauses, ", "), res.versionIncrement())
+	if partial {
+		runPartialBatch(w, r, tx, len(items), func(i int) batchResult {
+			updated, err := res.update(r, tx, updateQuery, args, items[i])
+			if err != nil {
+				return failedItem(i, err)
+			}
+			return batchResult{Index: i, Status: http.StatusOK, ID: res.id(&updated), Data: updated}
+		})
+		return
+	}
+
+	updatedItems := make([]T, 0, len(items))
+	for _, item := range items {
+		updated, err := res.update(r, tx, updateQuery, args, item)
+		if err != nil {
+			writeItemError(w, r, err)
+			return
+		}
+		updatedItems = append(updatedItems, updated)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+		Data   []T    `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(updatedItems),
+		Data:   updatedItems,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// update runs an UPDATE query with args on one record and records the change in the audit log.
+// The query must end with "WHERE id = ?", the id being appended to args.
+func (res *Resource[T]) update(r *http.Request, tx *sql.Tx, query string, args []any, item T) (T, error) {
+	var updated T
+	id := res.id(&item)
+	_, err := tx.ExecContext(r.Context(), query, append(slices.Clip(args), id)...)
+	if err != nil {
+		log.Printf("Error updating %s ID %d: %v", res.singular(), id, err)
+		if message, ok := sqlconnect.ConstraintViolation(err); ok {
+			return updated, &itemError{http.StatusUnprocessableEntity, "Invalid field value: " + message}
+		}
+		return updated, &itemError{http.StatusInternalServerError, "Error updating " + res.Table}
+	}
+
+	// Fetch the updated record
+	if err := dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`, id), &updated); err != nil {
+		log.Printf("Error querying updated %s ID %d: %v", res.singular(), id, err)
+		return updated, &itemError{http.StatusInternalServerError, "Error querying updated " + res.Table}
+	}
+	if err := recordMutation(r, tx, auditUpdate, res.Table, id, item, updated); err != nil {
+		return updated, &itemError{http.StatusInternalServerError, "Error writing audit log"}
+	}
+	return updated, nil
+}
+
+// DeleteOne deletes a single record by ID.
+func (res *Resource[T]) DeleteOne(w http.ResponseWriter, r *http.Request) {
+	id, ok := res.pathID(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var existing T
+	err = dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`+res.notDeleted()+` FOR UPDATE`, id), &existing)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, res.Singular+" not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error querying %s: %v", res.singular(), err)
+		responder.Error(w, r, "Error querying "+res.singular(), http.StatusInternalServerError)
+		return
+	}
+	if !res.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	if err := res.delete(r, tx, existing, time.Now().UTC()); err != nil {
+		writeItemError(w, r, err)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		ID     int    `json:"id"`
+	}{
+		Status: "success",
+		ID:     id,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// DeleteMany deletes the records matching the filters.
+func (res *Resource[T]) DeleteMany(w http.ResponseWriter, r *http.Request) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the matching records so their last state can be recorded in the audit log
+	items, ok := res.selectFiltered(w, r, tx)
+	if !ok {
+		return
+	}
+	if len(items) == 0 {
+		log.Printf("No %s found to delete", res.Table)
+		responder.Error(w, r, "No "+res.Table+" found to delete", http.StatusNotFound)
+		return
+	}
+
+	deletedAt := time.Now().UTC()
+	for _, item := range items {
+		if err := res.delete(r, tx, item, deletedAt); err != nil {
+			writeItemError(w, r, err)
+			return
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status       string `json:"status"`
+		CountDeleted int64  `json:"count_deleted"`
+	}{
+		Status:       "success",
+		CountDeleted: int64(len(items)),
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// delete removes a locked record and records it in the audit log. Soft-deletable records are
+// marked deleted at deletedAt; others are removed, unless other rows still reference them.
+func (res *Resource[T]) delete(r *http.Request, tx *sql.Tx, item T, deletedAt time.Time) error {
+	id := res.id(&item)
+	sd, soft := res.softDeletable()
+	var err error
+	if soft {
+		_, err = tx.ExecContext(r.Context(), `UPDATE `+res.Table+` SET `+sd.DeletedAtColumn()+` = ?`+res.versionIncrement()+` WHERE id = ?`, deletedAt, id)
+	} else {
+		_, err = tx.ExecContext(r.Context(), `DELETE FROM `+res.Table+` WHERE id = ?`, id)
+	}
+	if err != nil {
+		log.Printf("Error deleting %s ID %d: %v", res.singular(), id, err)
+		if message, ok := sqlconnect.ConstraintViolation(err); ok {
+			return &itemError{http.StatusConflict, res.Singular + " is still in use: " + message}
+		}
+		return &itemError{http.StatusInternalServerError, "Error deleting " + res.Table}
+	}
+
+	var after any
+	if soft {
+		var deleted T
+		if err := dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`, id), &deleted); err != nil {
+			log.Printf("Error querying deleted %s ID %d: %v", res.singular(), id, err)
+			return &itemError{http.StatusInternalServerError, "Error querying deleted " + res.Table}
+		}
+		after = deleted
+	}
+	if err := recordMutation(r, tx, auditDelete, res.Table, id, item, after); err != nil {
+		return &itemError{http.StatusInternalServerError, "Error writing audit log"}
+	}
+	return nil
+}
+
+// Restore undoes the soft delete of a single record by ID and audits the restore in the same transaction.
+func (res *Resource[T]) Restore(w http.ResponseWriter, r *http.Request) {
+	sd, ok := res.softDeletable()
+	if !ok {
+		responder.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := res.pathID(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	// Start transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		responder.Error(w, r, "Error starting transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	column := sd.DeletedAtColumn()
+	var deleted T
+	err = dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ? AND `+column+` IS NOT NULL FOR UPDATE`, id), &deleted)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "No deleted record found with that id", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error querying %s: %v", res.Table, err)
+		responder.Error(w, r, "Error querying record", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), `UPDATE `+res.Table+` SET `+column+` = NULL`+res.versionIncrement()+` WHERE id = ?`, id); err != nil {
+		log.Printf("Error restoring %s ID %d: %v", res.Table, id, err)
+		responder.Error(w, r, "Error restoring record", http.StatusInternalServerError)
+		return
+	}
+
+	var restored T
+	if err := dbmap.Scan(tx.QueryRowContext(r.Context(), res.selectQuery()+` WHERE id = ?`, id), &restored); err != nil {
+		log.Printf("Error querying restored %s ID %d: %v", res.Table, id, err)
+		responder.Error(w, r, "Error querying restored record", http.StatusInternalServerError)
+		return
+	}
+
+	if err := recordMutation(r, tx, auditRestore, res.Table, id, deleted, restored); err != nil {
+		responder.Error(w, r, "Error writing audit log", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		responder.Error(w, r, "Error committing transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if etag, ok := res.etag(r, restored); ok {
+		w.Header().Set("ETag", etag)
+	}
+	response := struct {
+		Status string `json:"status"`
+		Data   T      `json:"data"`
+	}{
+		Status: "success",
+		Data:   restored,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// selectFiltered locks and returns the live records matching the filters of a PATCH or DELETE on
+// the collection. It answers 400 when there are no filters and returns false on error.
+func (res *Resource[T]) selectFiltered(w http.ResponseWriter, r *http.Request, tx *sql.Tx) ([]T, bool) {
+	var model T
+	query, args, err := addFilters(r, res.selectQuery(), nil, model)
+	if err != nil {
+		log.Printf("Invalid request: %v", err)
+		responder.Error(w, r, "At least one valid filter is required", http.StatusBadRequest)
+		return nil, false
+	}
+	query += res.notDeleted()
+
+	rows, err := tx.QueryContext(r.Context(), query+" FOR UPDATE", args...)
+	if err != nil {
+		log.Printf("Error querying %s: %v", res.Table, err)
+		responder.Error(w, r, "Error querying "+res.Table, http.StatusInternalServerError)
+		return nil, false
+	}
+	defer rows.Close()
+
+	items := make([]T, 0)
+	for rows.Next() {
+		var item T
+		if err := dbmap.Scan(rows, &item); err != nil {
+			log.Printf("Error scanning database results: %v", err)
+			responder.Error(w, r, "Error scanning database results", http.StatusInternalServerError)
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating database results: %v", err)
+		responder.Error(w, r, "Error iterating database results", http.StatusInternalServerError)
+		return nil, false
+	}
+	return items, true
+}
+
+// pathID reads the id path value, answering 400 when it is not a positive integer.
+func (res *Resource[T]) pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		log.Printf("Invalid %s ID: %q", res.singular(), r.PathValue("id"))
+		responder.Error(w, r, "Invalid "+res.singular()+" id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// checkIfMatch enforces If-Match on versioned models; see etag.go.
+func (res *Resource[T]) checkIfMatch(w http.ResponseWriter, r *http.Request, current T) bool {
+	if v, ok := any(current).(models.Versioned); ok {
+		return checkIfMatch(w, r, v.RecordVersion())
+	}
+	return true
+}
+
+// etag returns the ETag of a record of a versioned model, in the format negotiated for r.
+func (res *Resource[T]) etag(r *http.Request, item T) (string, bool) {
+	if v, ok := any(item).(models.Versioned); ok {
+		return versionETag(r, v.RecordVersion()), true
+	}
+	return "", false
+}
+
+// hasVersion reports whether T is a versioned model.
+func (res *Resource[T]) hasVersion() bool {
+	var model T
+	_, ok := any(model).(models.Versioned)
+	return ok
+}
+
+// versionIncrement is the SET clause bumping the version of versioned models, to append to the others.
+func (res *Resource[T]) versionIncrement() string {
+	if res.hasVersion() {
+		return ", version = version + 1"
+	}
+	return ""
+}
+
+func (res *Resource[T]) softDeletable() (models.SoftDeletable, bool) {
+	var model T
+	sd, ok := any(model).(models.SoftDeletable)
+	return sd, ok
+}
+
+// notDeleted is the condition excluding soft-deleted rows, to append to a WHERE clause.
+func (res *Resource[T]) notDeleted() string {
+	if sd, ok := res.softDeletable(); ok {
+		return " AND " + sd.DeletedAtColumn() + " IS NULL"
+	}
+	return ""
+}
+
+// deleted reports whether item was soft deleted.
+func (res *Resource[T]) deleted(item *T) bool {
+	sd, ok := res.softDeletable()
+	if !ok {
+		return false
+	}
+	column, ok := dbmap.Find(item, sd.DeletedAtColumn())
+	return ok && !reflect.ValueOf(column.Value(item)).IsZero()
+}
+
+// id returns the primary key of item, held by the "id" column.
+func (res *Resource[T]) id(item *T) int {
+	column, _ := dbmap.Find(item, "id")
+	return column.Value(item).(int)
+}
+
+func (res *Resource[T]) singular() string {
+	return strings.ToLower(res.Singular)
+}
+
+func (res *Resource[T]) selectQuery() string {
+	var model T
+	return `SELECT ` + dbmap.List(model) + ` FROM ` + res.Table
+}
+
+// insertQuery is the INSERT of a new record's writable columns, prepared once per batch.
+func (res *Resource[T]) insertQuery() string {
+	columns := dbmap.Names(res.writableColumns())
+	return fmt.Sprintf(`INSERT INTO %s (%s) VALUES (?%s)`, res.Table, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)-1))
+}
+
+// writableColumns lists the columns clients may set: those whose field is not validate:"readonly".
+func (res *Resource[T]) writableColumns() []dbmap.Column {
+	var model T
+	writable := validator.WritableFields(model)
+	var columns []dbmap.Column
+	for _, column := range dbmap.Columns(model) {
+		if slices.Contains(writable, column.Field) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// fieldColumns maps the JSON names of the writable fields to their columns, for updateClauses.
+func (res *Resource[T]) fieldColumns() map[string]string {
+	columns := make(map[string]string)
+	for _, column := range res.writableColumns() {
+		columns[column.Field] = column.Name
+	}
+	return columns
+}
+
+// recordFields returns the JSON values of every column of record, a struct or pointer to struct,
+// keyed by JSON name. Unlike the JSON of the models it keeps empty and zero values, and null for NULL.
+func recordFields(record any) (map[string]any, error) {
+	fields := make(map[string]any)
+	for _, column := range dbmap.Columns(record) {
+		data, err := json.Marshal(column.Value(record))
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		fields[column.Field] = value
+	}
+	return fields, nil
+}
+
+// columnValues returns the values of the given columns of item, in order.
+func columnValues(item any, columns []dbmap.Column) []any {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = column.Value(item)
+	}
+	return values
+}
@@ -4,10 +4,17 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
 	"github.com/jorge-sader/go-rest-api/pkg/utils"
 )
 
+// RootHandler greets requests to "/". As the catch-all route it also answers every path no other
+// route matches, with a 404 problem.
 func RootHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		responder.Error(w, r, "No route matches "+r.URL.Path, http.StatusNotFound)
+		return
+	}
 	utils.LogRequestDetails(r)
 	fmt.Fprintln(w, "Hello Gorgeous")
 	w.Write([]byte("You look fantastic today ;)"))
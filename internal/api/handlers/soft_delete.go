@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// auditRestore is the audit action recorded when a soft-deleted record is restored.
+const auditRestore = "restore"
+
+// includeDeleted reports whether the request asked for soft-deleted rows with ?include_deleted=true.
+// Only principals granted "<resource>:read_deleted" (admins in the default policy) may ask;
+// anyone else gets a 403 and ok is false.
+func includeDeleted(w http.ResponseWriter, r *http.Request, resource string) (include bool, ok bool) {
+	if r.URL.Query().Get("include_deleted") != "true" {
+		return false, true
+	}
+
+	principal, _ := utils.PrincipalFromContext(r.Context())
+	if !middlewares.Can(principal, resource, "read_deleted") {
+		responder.Error(w, r, "Forbidden: missing permission "+resource+":read_deleted", http.StatusForbidden)
+		return false, false
+	}
+	return true, true
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+const recoveryCodeCount = 10
+
+// writeMFAChallenge answers a correct password for a 2FA-enrolled executive with a partial token.
+func writeMFAChallenge(w http.ResponseWriter, r *http.Request, exec models.Executive) {
+	mfaToken, err := utils.SignMFAToken(principalFor(exec, ""))
+	if err != nil {
+		log.Printf("Error signing MFA token: %v", err)
+		responder.Error(w, r, "Error signing token", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status   string `json:"status"`
+		MFAToken string `json:"mfa_token"`
+	}{
+		Status:   "mfa_required",
+		MFAToken: mfaToken,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// EnrollTOTPHandler generates a new TOTP secret for the authenticated executive and returns it
+// with its otpauth URI. Two-factor authentication is only enabled once ConfirmTOTPHandler succeeds.
+func EnrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := executiveFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		responder.Error(w, r, "Error generating TOTP secret", http.StatusInternalServerError)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	rowsAffected, err := sqlconnect.SetPendingTOTPSecret(r.Context(), db, principal.ID, secret)
+	if err != nil {
+		responder.Error(w, r, "Error storing TOTP secret", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		responder.Error(w, r, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "go-rest-api"
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Secret string `json:"secret"`
+		URI    string `json:"otpauth_uri"`
+	}{
+		Status: "success",
+		Secret: secret,
+		URI:    utils.TOTPURI(issuer, principal.Username, secret),
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// ConfirmTOTPHandler enables two-factor authentication once the executive proves their authenticator
+// produces valid codes, and returns single-use recovery codes. The codes are only shown this once.
+func ConfirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := executiveFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Code == "" {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	exec, err := sqlconnect.GetExecutiveByID(r.Context(), db, principal.ID)
+	if err == sql.ErrNoRows {
+		responder.Error(w, r, "Executive not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+	if exec.TOTPEnabled {
+		responder.Error(w, r, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if exec.TOTPSecret == "" {
+		responder.Error(w, r, "No pending enrollment: POST /auth/2fa/enroll first", http.StatusBadRequest)
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(exec.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		responder.Error(w, r, "Invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		responder.Error(w, r, "Error generating recovery codes", http.StatusInternalServerError)
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+
+	if err := sqlconnect.EnableTOTP(r.Context(), db, exec.ID, step, hashes); err != nil {
+		log.Printf("Error enabling TOTP for executive %d: %v", exec.ID, err)
+		responder.Error(w, r, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	// Any enrollment restriction in the caller's token is lifted on the next POST /auth/refresh.
+	response := struct {
+		Status        string   `json:"status"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		Status:        "success",
+		RecoveryCodes: codes,
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// VerifyTOTPHandler completes a two-step login by exchanging the partial token from LoginHandler
+// plus a TOTP code or an unused recovery code for a full access/refresh token pair.
+func VerifyTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		responder.Error(w, r, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := utils.ParseToken(req.MFAToken)
+	if err != nil || claims.Purpose != utils.PurposeMFA {
+		responder.Error(w, r, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	exec, err := sqlconnect.GetExecutiveByID(r.Context(), db, claims.UserID)
+	if err == sql.ErrNoRows || (err == nil && !exec.TOTPEnabled) {
+		responder.Error(w, r, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		responder.Error(w, r, "Error querying executive", http.StatusInternalServerError)
+		return
+	}
+
+	// Second-factor guesses count towards the same per-username lockout as passwords
+	username := normalizeUsername(exec.Username)
+	if rejectIfLocked(w, r, db, username) {
+		return
+	}
+
+	var verified bool
+	if req.Code != "" {
+		if step, ok := utils.ValidateTOTP(exec.TOTPSecret, req.Code, time.Now()); ok {
+			// Each code is single-use: the step must be newer than the last accepted one
+			verified, err = sqlconnect.AdvanceTOTPStep(r.Context(), db, exec.ID, step)
+		}
+	} else {
+		code := strings.ToLower(strings.TrimSpace(req.RecoveryCode))
+		verified, err = sqlconnect.ConsumeRecoveryCode(r.Context(), db, exec.ID, utils.HashToken(code))
+		if verified {
+			log.Printf("Executive %d logged in with a recovery code", exec.ID)
+		}
+	}
+	if err != nil {
+		log.Printf("Error verifying second factor for executive %d: %v", exec.ID, err)
+		responder.Error(w, r, "Error verifying code", http.StatusInternalServerError)
+		return
+	}
+	if !verified {
+		registerLoginFailure(r, db, username)
+		responder.Error(w, r, "Invalid code", http.StatusUnauthorized)
+		return
+	}
+	sqlconnect.ClearLoginFailures(r.Context(), db, username)
+
+	startSession(w, r, db, exec)
+}
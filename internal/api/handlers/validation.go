@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// Create, PUT and PATCH payloads are checked against the validate tags on the models before they
+// reach the database. Every failing field is reported at once in the errors member of a 422 problem
+// (see responder.ValidationFailed). Batch requests prefix field names with the item index, e.g. "[2].email".
+
+// payloadValidator returns a validator whose foreign-key checks run inside tx, so they see the
+// rows the transaction itself created or removed.
+func payloadValidator(tx *sql.Tx) validator.Validator {
+	return validator.Validator{
+		Exists: func(ctx context.Context, table string, id any) (bool, error) {
+			return sqlconnect.RecordExists(ctx, tx, table, id)
+		},
+	}
+}
+
+// validateRecord checks a complete record for a create or PUT. It writes the error response and
+// returns false when the record is invalid or could not be checked.
+func validateRecord(w http.ResponseWriter, r *http.Request, tx *sql.Tx, record any) bool {
+	errs, err := payloadValidator(tx).Struct(r.Context(), record)
+	if err != nil {
+		log.Printf("Error validating payload: %v", err)
+		responder.Error(w, r, "Error validating payload", http.StatusInternalServerError)
+		return false
+	}
+	if len(errs) > 0 {
+		responder.ValidationFailed(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// validateUpdates checks the fields of a PATCH against model and converts their values to the
+// model's field types. It writes the error response and returns false when the update is invalid.
+func validateUpdates(w http.ResponseWriter, r *http.Request, tx *sql.Tx, model any, updates map[string]any) bool {
+	errs, err := payloadValidator(tx).Fields(r.Context(), model, updates)
+	if err != nil {
+		log.Printf("Error validating payload: %v", err)
+		responder.Error(w, r, "Error validating payload", http.StatusInternalServerError)
+		return false
+	}
+	if len(errs) > 0 {
+		responder.ValidationFailed(w, r, errs)
+		return false
+	}
+	return true
+}
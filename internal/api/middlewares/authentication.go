@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// Authenticate resolves the caller and stores it in the request context. Supported credentials:
+//
+//	Authorization: Bearer <access token>   executives (HS256, see POST /executives/login)
+//	                                       or OAuth2 clients (RS256, see POST /oauth/token), while not revoked
+//	Authorization: ApiKey <key>            machine integrations
+//	X-API-Key: <key>                       machine integrations
+//	verified TLS client certificate        integrations using mutual TLS (see mtls.go)
+//
+// Explicit credentials in headers take precedence over a client certificate.
+// Requests without credentials pass through unauthenticated so public routes keep working;
+// routes that need an identity are guarded by Authorize. Invalid credentials are always rejected.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-Key")
+		var bearer string
+
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			scheme, credentials, _ := strings.Cut(authHeader, " ")
+			credentials = strings.TrimSpace(credentials)
+			switch {
+			case strings.EqualFold(scheme, "Bearer"):
+				bearer = credentials
+			case strings.EqualFold(scheme, "ApiKey"):
+				apiKey = credentials
+			}
+		}
+
+		switch {
+		case bearer != "" && utils.IsOAuthToken(bearer):
+			claims, err := utils.ParseOAuthToken(bearer)
+			if err == nil {
+				err = checkOAuthClient(r.Context(), claims.ClientID)
+			}
+			if err != nil {
+				log.Printf("Invalid OAuth access token: %v", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				responder.Error(w, r, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(utils.WithPrincipal(r.Context(), claims.Principal()))
+
+		case bearer != "":
+			claims, err := utils.ParseToken(bearer)
+			if err == nil && claims.Purpose != "" {
+				err = fmt.Errorf("%s token cannot be used as an access token", claims.Purpose)
+			}
+			if err != nil {
+				log.Printf("Invalid access token: %v", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				responder.Error(w, r, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(utils.WithPrincipal(r.Context(), claims.Principal()))
+
+		case apiKey != "":
+			principal, err := authenticateAPIKey(r.Context(), apiKey)
+			if err != nil {
+				log.Printf("Rejected API key: %v", err)
+				w.Header().Set("WWW-Authenticate", "ApiKey")
+				responder.Error(w, r, "Invalid, expired or revoked API key", http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(utils.WithPrincipal(r.Context(), principal))
+
+		default:
+			if cert := verifiedClientCert(r); cert != nil {
+				if principal, ok := identityFromCert(cert); ok {
+					r = r.WithContext(utils.WithPrincipal(r.Context(), principal))
+				} else {
+					log.Printf("Verified client certificate %q is not mapped to an identity", cert.Subject.String())
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// oauthClientTTL is how long the state of an OAuth2 client is cached: tokens of a client revoked
+// on another instance are rejected at most this long after the revocation.
+const oauthClientTTL = 30 * time.Second
+
+type oauthClientState struct {
+	err     error // why the client's tokens are rejected, nil when it is active
+	checked time.Time
+}
+
+var (
+	oauthClientsMu sync.Mutex
+	oauthClients   = make(map[string]oauthClientState)
+)
+
+// checkOAuthClient rejects the tokens of OAuth2 clients that were revoked or deleted since the
+// tokens were issued; signatures alone stay valid until the tokens expire.
+func checkOAuthClient(ctx context.Context, clientID string) error {
+	oauthClientsMu.Lock()
+	state, ok := oauthClients[clientID]
+	oauthClientsMu.Unlock()
+	if ok && time.Since(state.checked) < oauthClientTTL {
+		return state.err
+	}
+
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	client, err := sqlconnect.GetOAuthClientByClientID(ctx, db, clientID)
+	switch {
+	case err == sql.ErrNoRows:
+		state.err = fmt.Errorf("unknown OAuth client %s", clientID)
+	case err != nil:
+		return err // not cached: the next request checks again
+	case client.RevokedAt != nil:
+		state.err = fmt.Errorf("OAuth client %s is revoked", clientID)
+	default:
+		state.err = nil
+	}
+	state.checked = time.Now()
+
+	oauthClientsMu.Lock()
+	oauthClients[clientID] = state
+	oauthClientsMu.Unlock()
+	return state.err
+}
+
+// ForgetOAuthClients clears the cached state of OAuth2 clients, so that a revocation takes effect
+// on this instance immediately.
+func ForgetOAuthClients() {
+	oauthClientsMu.Lock()
+	clear(oauthClients)
+	oauthClientsMu.Unlock()
+}
+
+// authenticateAPIKey validates an API key and records its use.
+func authenticateAPIKey(ctx context.Context, key string) (utils.Principal, error) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		return utils.Principal{}, err
+	}
+	defer db.Close()
+
+	apiKey, err := sqlconnect.GetAPIKeyByHash(ctx, db, utils.HashToken(key))
+	if err == sql.ErrNoRows {
+		return utils.Principal{}, fmt.Errorf("unknown API key")
+	} else if err != nil {
+		return utils.Principal{}, err
+	}
+	if apiKey.RevokedAt != nil {
+		return utils.Principal{}, fmt.Errorf("API key %s is revoked", apiKey.Prefix)
+	}
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return utils.Principal{}, fmt.Errorf("API key %s expired", apiKey.Prefix)
+	}
+
+	if err := sqlconnect.TouchAPIKey(ctx, db, apiKey.ID); err != nil {
+		log.Printf("Error recording use of API key %s: %v", apiKey.Prefix, err)
+	}
+
+	return utils.Principal{
+		Kind:     utils.PrincipalAPIKey,
+		ID:       apiKey.ID,
+		Username: "apikey:" + apiKey.Prefix,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
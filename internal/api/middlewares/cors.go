@@ -3,6 +3,8 @@ package middlewares
 import (
 	"net/http"
 	"slices"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
 )
 
 var allowedOrigins = []string{
@@ -15,15 +17,23 @@ func Cors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("origin")
 
+		// Requests without an Origin header come from servers and CLI tools (e.g. OAuth2 clients),
+		// not browsers, so CORS does not apply to them.
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		if slices.Contains(allowedOrigins, origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
 		} else {
-			http.Error(w, "Not allowed by CORS", http.StatusForbidden)
+			responder.Error(w, r, "Not allowed by CORS", http.StatusForbidden)
 			return
 		}
 
-		w.Header().Set("access-control-allow-headers", "Content-type, Authorization")
-		w.Header().Set("access-control-expose-headers", "Authorization")
+		w.Header().Set("access-control-allow-headers", "Content-type, Authorization, X-API-Key, X-Request-ID, If-Match, If-None-Match, Idempotency-Key")
+		w.Header().Set("access-control-expose-headers", "Authorization, X-Request-ID, ETag, Idempotent-Replayed")
 		w.Header().Set("access-control-allow-methods", "GET, POST, PUT, PATCH, DELETE")
 		w.Header().Set("access-control-allow-credentials", "true")
 		w.Header().Set("access-control-max-age", "3600")
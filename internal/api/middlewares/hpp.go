@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
 )
 
 type HPPOptions struct {
@@ -23,7 +25,10 @@ func Hpp(options HPPOptions) func(next http.Handler) http.Handler {
 					r.Method == http.MethodPut ||
 					r.Method == http.MethodPatch) &&
 				isValidContentType(r, options.CheckBodyOnlyForContentType) {
-				filterBodyParams(r, options.Whitelist)
+				if err := filterBodyParams(r, options.Whitelist); err != nil {
+					responder.Error(w, r, "Invalid form body", http.StatusBadRequest)
+					return
+				}
 			}
 
 			if options.CheckQuery && r.URL.Query() != nil {
@@ -54,11 +59,11 @@ func filterQueryParams(r *http.Request, whitelist []string) {
 	r.URL.RawQuery = query.Encode()
 }
 
-func filterBodyParams(r *http.Request, whitelist []string) {
+func filterBodyParams(r *http.Request, whitelist []string) error {
 	err := r.ParseForm()
 	if err != nil {
 		fmt.Println(err)
-		return
+		return err
 	}
 
 	for k, v := range r.Form {
@@ -70,4 +75,5 @@ func filterBodyParams(r *http.Request, whitelist []string) {
 			delete(r.Form, k)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,300 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodySize   = 10 << 20
+	// Larger bodies, such as spreadsheet uploads, are spooled to a temporary file to be fingerprinted.
+	maxIdempotentMemory = 1 << 20
+	// A request in progress holds its key for idempotencyLease, renewed while it runs, so the key of
+	// a request whose process died can be used again soon after.
+	idempotencyLease = 30 * time.Second
+	// A reservation released between the attempt to take it and the read of its outcome is retried.
+	maxReserveAttempts = 3
+)
+
+// replayedHeaders are the response headers stored with an idempotent response and sent again on replay.
+var replayedHeaders = []string{"Content-Type", "Vary", "Location", "ETag"}
+
+// IdempotencyTTL reads IDEMPOTENCY_KEY_TTL (e.g. "24h"), defaulting to 24 hours.
+func IdempotencyTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil || ttl <= 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
+
+// Idempotent makes POST and PATCH requests that carry an Idempotency-Key header safe to retry.
+// The first request with a key runs normally and its response is stored together with a fingerprint
+// of the method, URL, formats and body. A retry with the same key and fingerprint gets the stored response
+// replayed (marked with Idempotent-Replayed: true) instead of running again; reusing the key for a
+// different request is rejected with 422, and a retry while the first request is still running gets 409.
+// A request holds its key with a lease renewed while it runs (see idempotencyLease), so a key is not
+// stuck in progress when the process handling it dies.
+// Keys are scoped to the authenticated principal, so it must run after Authorize, and expire after
+// IdempotencyTTL. Server errors are not stored so the request can be retried with the same key.
+//
+// Only the fingerprint and the response are stored, never the request body.
+//
+// It guards every POST and PATCH route but those of the authentication and credential endpoints
+// (login, /auth/, OAuth2 tokens and clients, API keys), whose responses are never persisted and
+// which reject Idempotency-Key instead (see RejectIdempotencyKey).
+func Idempotent(next http.Handler) http.Handler {
+	return IdempotentUpTo(maxIdempotentBodySize)(next)
+}
+
+// IdempotentUpTo is Idempotent for routes taking bodies of up to limit bytes, such as imports.
+func IdempotentUpTo(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return idempotent(next, limit)
+	}
+}
+
+func idempotent(next http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			responder.Error(w, r, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		bodySum, cleanup, err := spoolBody(w, r, limit)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			responder.Error(w, r, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			log.Printf("Error reading request body: %v", err)
+			responder.Error(w, r, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		defer cleanup()
+
+		principal := "anonymous"
+		if p, ok := utils.PrincipalFromContext(r.Context()); ok {
+			principal = p.Kind + ":" + p.Username
+		}
+		fingerprint := requestFingerprint(r, bodySum)
+
+		db, err := sqlconnect.ConnectDB()
+		if err != nil {
+			log.Printf("Error connecting to database: %v", err)
+			responder.Error(w, r, "Error connecting to database", http.StatusInternalServerError)
+			return
+		}
+		defer db.Close()
+
+		reserved, stored, err := reserveIdempotencyKey(r.Context(), db, principal, key, fingerprint)
+		if err != nil {
+			responder.Error(w, r, "Error checking idempotency key", http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				responder.Error(w, r, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			case stored.Status == 0:
+				responder.Error(w, r, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+			default:
+				for name, value := range stored.Headers {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+			}
+			return
+		}
+
+		// Store the outcome even if the client has gone away: that is exactly when it will retry
+		ctx := context.WithoutCancel(r.Context())
+		defer renewIdempotencyKey(ctx, db, principal, key)()
+		recorder := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			sqlconnect.ReleaseIdempotencyKey(ctx, db, principal, key)
+			return
+		}
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := sqlconnect.CompleteIdempotencyKey(ctx, db, principal, key, recorder.status, headers, recorder.body.Bytes()); err != nil {
+			sqlconnect.ReleaseIdempotencyKey(ctx, db, principal, key)
+		}
+	})
+}
+
+// reserveIdempotencyKey reserves key for a request, or returns the entry of the request that holds it.
+func reserveIdempotencyKey(ctx context.Context, db *sql.DB, principal, key, fingerprint string) (bool, models.IdempotencyKey, error) {
+	for attempt := 1; ; attempt++ {
+		now := time.Now().UTC()
+		reserved, err := sqlconnect.ReserveIdempotencyKey(ctx, db, models.IdempotencyKey{
+			Principal:   principal,
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(IdempotencyTTL()),
+			LockedUntil: now.Add(idempotencyLease),
+		})
+		if err != nil || reserved {
+			return reserved, models.IdempotencyKey{}, err
+		}
+
+		stored, err := sqlconnect.GetIdempotencyKey(ctx, db, principal, key)
+		if err == sql.ErrNoRows && attempt < maxReserveAttempts {
+			// Released by its request since the reservation failed: try again
+			continue
+		}
+		if err != nil {
+			log.Printf("Error querying idempotency key: %v", err)
+			return false, models.IdempotencyKey{}, err
+		}
+		return false, stored, nil
+	}
+}
+
+// renewIdempotencyKey extends the lease of a reserved key until the returned function is called.
+func renewIdempotencyKey(ctx context.Context, db *sql.DB, principal, key string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sqlconnect.RenewIdempotencyKey(ctx, db, principal, key, time.Now().Add(idempotencyLease))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// RejectIdempotencyKey guards POST routes whose responses must never be stored, such as those
+// issuing credentials: requests with an Idempotency-Key get 400 rather than a silent non-idempotent run.
+func RejectIdempotencyKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Idempotency-Key") != "" {
+			responder.Error(w, r, "Idempotency-Key is not supported by this endpoint", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// spoolBody reads the request body up to limit bytes and returns its SHA-256, replacing r.Body
+// with a copy for the handler. Bodies over maxIdempotentMemory are copied to a temporary file,
+// which cleanup removes.
+func spoolBody(w http.ResponseWriter, r *http.Request, limit int64) (sum []byte, cleanup func(), err error) {
+	h := sha256.New()
+	body := io.TeeReader(http.MaxBytesReader(w, r.Body, limit), h)
+	head, err := io.ReadAll(io.LimitReader(body, maxIdempotentMemory+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(head) <= maxIdempotentMemory {
+		r.Body = io.NopCloser(bytes.NewReader(head))
+		return h.Sum(nil), func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "idempotent-body-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err := f.Write(head); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	r.Body = f
+	return h.Sum(nil), cleanup, nil
+}
+
+// requestFingerprint identifies a request by method, path, query, body and formats: the same body
+// sent as another Content-Type, or asking for the response in another format, is another request.
+// bodySum is the SHA-256 of the body.
+func requestFingerprint(r *http.Request, bodySum []byte) string {
+	accept := r.Header.Get("Accept")
+	if c, ok := codec.Negotiate(accept); ok {
+		accept = c.MediaType()
+	}
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		contentType = r.Header.Get("Content-Type")
+	}
+
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	io.WriteString(h, "Accept: "+accept+"\nContent-Type: "+contentType+"\n\n")
+	h.Write(bodySum)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyRecorder passes a response through while keeping a copy of its status and body.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// ClientIdentity is the identity and RBAC role assigned to a verified client certificate.
+type ClientIdentity struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// clientIdentities maps certificate names to identities. Keys are prefixed with the part of the
+// certificate they match: "cn:" subject common name, "dns:", "email:" or "uri:" subject alternative names.
+//
+//	{
+//	  "cn:sis-sync":                        { "name": "sis-sync", "role": "integration" },
+//	  "uri:spiffe://district.example/report": { "name": "reporting", "role": "counselor" }
+//	}
+var (
+	clientIdentitiesMu sync.RWMutex
+	clientIdentities   map[string]ClientIdentity
+)
+
+// LoadClientIdentities reads the certificate-to-identity mapping used for mutual TLS.
+func LoadClientIdentities(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading client identities: %w", err)
+	}
+
+	var identities map[string]ClientIdentity
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return fmt.Errorf("parsing client identities: %w", err)
+	}
+	for key, identity := range identities {
+		if identity.Name == "" || identity.Role == "" {
+			return fmt.Errorf("client identity %q needs a name and a role", key)
+		}
+	}
+
+	clientIdentitiesMu.Lock()
+	clientIdentities = identities
+	clientIdentitiesMu.Unlock()
+	return nil
+}
+
+// verifiedClientCert returns the leaf certificate the TLS handshake verified, if any.
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// identityFromCert maps a verified certificate to a principal, checking the subject CN first
+// and then each SAN.
+func identityFromCert(cert *x509.Certificate) (utils.Principal, bool) {
+	keys := []string{"cn:" + cert.Subject.CommonName}
+	for _, name := range cert.DNSNames {
+		keys = append(keys, "dns:"+name)
+	}
+	for _, email := range cert.EmailAddresses {
+		keys = append(keys, "email:"+email)
+	}
+	for _, uri := range cert.URIs {
+		keys = append(keys, "uri:"+uri.String())
+	}
+
+	clientIdentitiesMu.RLock()
+	defer clientIdentitiesMu.RUnlock()
+	for _, key := range keys {
+		if identity, ok := clientIdentities[key]; ok {
+			return utils.Principal{
+				Kind:     utils.PrincipalClientCert,
+				Username: "cert:" + identity.Name,
+				Role:     identity.Role,
+			}, true
+		}
+	}
+	return utils.Principal{}, false
+}
+
+// IntegrationRole is the RBAC role of integration partners, such as the roster sync.
+const IntegrationRole = "integration"
+
+// RequireClientCertForIntegrations guards the data routes integrations write through. With
+// MTLS_MODE=required, callers holding IntegrationRole must have authenticated with a verified and
+// mapped client certificate, as RequireClientCert demands, rather than with an executive account
+// given the role. Other roles, and every caller in the other modes, pass. It runs after Authorize.
+func RequireClientCertForIntegrations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := utils.PrincipalFromContext(r.Context())
+		if ok && principal.Role == IntegrationRole && principal.Kind != utils.PrincipalClientCert && os.Getenv("MTLS_MODE") == "required" {
+			log.Printf("Denied %s %s for %s: integrations must use a client certificate", r.Method, r.URL.Path, principal.Username)
+			responder.Error(w, r, "Integrations must authenticate with a verified client certificate", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireClientCert guards a route so it is only reachable with a verified and mapped client certificate,
+// regardless of the server-wide MTLS_MODE. Use it for integration endpoints.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cert := verifiedClientCert(r)
+		if cert == nil {
+			responder.Error(w, r, "A verified client certificate is required", http.StatusUnauthorized)
+			return
+		}
+		if _, ok := identityFromCert(cert); !ok {
+			responder.Error(w, r, "Client certificate is not mapped to an identity", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/jorge-sader/go-rest-api/pkg/codec"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+)
+
+// Negotiate rejects requests that change state with 406 Not Acceptable when their Accept header
+// allows none of the response formats of pkg/codec, before the handler runs, so that a client
+// never gets a 406 for a write that has already been committed. Safe methods are left to the
+// handlers, which negotiate when they write (exports offer formats of their own).
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if _, ok := codec.Negotiate(r.Header.Get("Accept")); !ok {
+				responder.NotAcceptable(w, r, codec.MediaTypes()...)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
 )
 
 type rateLimiter struct {
@@ -45,7 +47,7 @@ func (rl *rateLimiter) Middleware(next http.Handler) http.Handler {
 		fmt.Printf("\nVisitor Count from %v, is %v\n", visitorIP, rl.visitors[visitorIP])
 
 		if rl.visitors[visitorIP] > rl.limit {
-			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			responder.Error(w, r, "too many requests", http.StatusTooManyRequests)
 			return
 		}
 		next.ServeHTTP(w, r)
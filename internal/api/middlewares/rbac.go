@@ -0,0 +1,218 @@
+package middlewares
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/sqlconnect"
+	"github.com/jorge-sader/go-rest-api/internal/models"
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// Policy maps each role to the actions it may perform per resource.
+// "*" may be used as a wildcard for either the resource or the action.
+//
+// Roles listed in mfa_required_roles must enroll in two-factor authentication before
+// they can use any authorized route.
+//
+//	{
+//	  "roles": {
+//	    "admin":     { "*": ["*"] },
+//	    "principal": { "students": ["read", "update"] }
+//	  },
+//	  "mfa_required_roles": ["admin"]
+//	}
+type Policy struct {
+	Roles            map[string]map[string][]string `json:"roles"`
+	MFARequiredRoles []string                       `json:"mfa_required_roles"`
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   Policy
+)
+
+// LoadPolicy reads the RBAC policy file and makes it the active policy.
+func LoadPolicy(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading RBAC policy: %w", err)
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("parsing RBAC policy: %w", err)
+	}
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("RBAC policy %s defines no roles", path)
+	}
+	for role := range p.Roles {
+		if !slices.Contains(executiveRoles(), role) {
+			return fmt.Errorf("RBAC policy %s defines role %q, which executives cannot be given: add it to the oneof of the Role field in internal/models/defs/executive.yaml", path, role)
+		}
+	}
+
+	policyMu.Lock()
+	policy = p
+	policyMu.Unlock()
+	return nil
+}
+
+// executiveRoles returns the roles an executive may be given, the oneof of the Role field.
+func executiveRoles() []string {
+	field, _ := reflect.TypeFor[models.Executive]().FieldByName("Role")
+	for rule := range strings.SplitSeq(field.Tag.Get("validate"), ",") {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+			return strings.Fields(values)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether role may perform action on resource.
+func (p Policy) Allows(role, resource, action string) bool {
+	resources, ok := p.Roles[role]
+	if !ok {
+		return false
+	}
+	for _, key := range []string{resource, "*"} {
+		actions := resources[key]
+		if slices.Contains(actions, action) || slices.Contains(actions, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesAllow reports whether a list of "<resource>:<action>" scopes grants action on resource.
+// "*" may be used for either part, e.g. "students:*" or "*:read".
+func ScopesAllow(scopes []string, resource, action string) bool {
+	for _, scope := range scopes {
+		scopeResource, scopeAction, ok := strings.Cut(scope, ":")
+		if !ok {
+			continue
+		}
+		if (scopeResource == resource || scopeResource == "*") && (scopeAction == action || scopeAction == "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether principal may perform action on resource: by scopes for API keys and
+// OAuth2 clients, by role through the active policy for everyone else. Handlers use it for
+// permissions finer than the route, e.g. "students:read_deleted".
+func Can(principal utils.Principal, resource, action string) bool {
+	if principal.ScopeBased() {
+		return ScopesAllow(principal.Scopes, resource, action)
+	}
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy.Allows(principal.Role, resource, action)
+}
+
+// RoleRequiresMFA reports whether the active policy makes two-factor authentication mandatory for role.
+func RoleRequiresMFA(role string) bool {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return slices.Contains(policy.MFARequiredRoles, role)
+}
+
+// methodActions maps HTTP methods to policy actions.
+var methodActions = map[string]string{
+	http.MethodGet:    "read",
+	http.MethodHead:   "read",
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "update",
+	http.MethodDelete: "delete",
+}
+
+// Authorize guards a route so only principals whose role grants "<resource>:<action>" may call it.
+// The action is derived from the request method, which also covers routes that dispatch on method themselves.
+func Authorize(resource string) func(next http.Handler) http.Handler {
+	return authorize(resource, "")
+}
+
+// AuthorizeAction guards a route that does not map onto CRUD semantics with an explicit action,
+// e.g. AuthorizeAction("executives", "force_password_change").
+func AuthorizeAction(resource, action string) func(next http.Handler) http.Handler {
+	return authorize(resource, action)
+}
+
+func authorize(resource, fixedAction string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := utils.PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				responder.Error(w, r, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			// The flag in an access token is as old as the token, so forcing or completing a
+			// password change takes effect on tokens already issued; so does revoking the session
+			// the token was issued for (logout, revoked sessions, password changes)
+			if principal.Kind == utils.PrincipalExecutive {
+				must, active, err := sessionState(r.Context(), principal.ID, principal.Session)
+				if err == sql.ErrNoRows || err == nil && !active {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					responder.Error(w, r, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				} else if err != nil {
+					log.Printf("Error checking password change for executive %d: %v", principal.ID, err)
+					responder.Error(w, r, "Error checking account", http.StatusInternalServerError)
+					return
+				}
+				principal.MustChangePassword = must
+			}
+			if principal.MustChangePassword {
+				responder.Error(w, r, "Password change required: POST /auth/change-password", http.StatusForbidden)
+				return
+			}
+			if principal.MFAEnrollRequired {
+				responder.Error(w, r, "Two-factor authentication required for role "+principal.Role+": POST /auth/2fa/enroll", http.StatusForbidden)
+				return
+			}
+
+			action := fixedAction
+			if action == "" {
+				action, ok = methodActions[r.Method]
+				if !ok {
+					responder.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+			}
+
+			if !Can(principal, resource, action) {
+				permission := resource + ":" + action
+				log.Printf("Denied %s %s for %s %s (role %q): missing permission %s", r.Method, r.URL.Path, principal.Kind, principal.Username, principal.Role, permission)
+				responder.Error(w, r, "Forbidden: missing permission "+permission, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sessionState reads the current forced password change flag of an executive and whether the
+// session of its access token is still active.
+func sessionState(ctx context.Context, id int, session string) (mustChangePassword, active bool, err error) {
+	db, err := sqlconnect.ConnectDB()
+	if err != nil {
+		return false, false, err
+	}
+	defer db.Close()
+	return sqlconnect.GetSessionState(ctx, db, id, session)
+}
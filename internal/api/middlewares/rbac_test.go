@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+const testPolicy = `{
+	"roles": {
+		"admin":     {"*": ["*"]},
+		"principal": {"students": ["read", "update"], "*": ["read"]},
+		"registrar": {"students": ["*"], "teachers": ["read"]},
+		"counselor": {}
+	},
+	"mfa_required_roles": ["admin"]
+}`
+
+// loadPolicy writes data to a policy file and loads it, restoring the active policy after the test.
+func loadPolicy(t *testing.T, data string) error {
+	t.Helper()
+	policyMu.RLock()
+	previous := policy
+	policyMu.RUnlock()
+	t.Cleanup(func() {
+		policyMu.Lock()
+		policy = previous
+		policyMu.Unlock()
+	})
+
+	path := filepath.Join(t.TempDir(), "rbac_policy.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadPolicy(path)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string // empty when the policy loads
+	}{
+		{name: "valid", data: testPolicy},
+		{name: "invalid JSON", data: `{"roles": `, wantErr: "parsing"},
+		{name: "no roles", data: `{"roles": {}}`, wantErr: "no roles"},
+		{name: "wrong shape", data: `{"roles": {"admin": ["*"]}}`, wantErr: "parsing"},
+		{name: "unknown role", data: `{"roles": {"admin": {"*": ["*"]}, "janitor": {"*": ["read"]}}}`, wantErr: `"janitor"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadPolicy(t, tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadPolicy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPolicy() error = %v, want one mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := LoadPolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadPolicy() of a missing file succeeded")
+	}
+}
+
+// TestShippedPolicy loads the policy the server reads by default.
+func TestShippedPolicy(t *testing.T) {
+	data, err := os.ReadFile("../../../cmd/api/rbac_policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadPolicy(t, string(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCan(t *testing.T) {
+	if err := loadPolicy(t, testPolicy); err != nil {
+		t.Fatal(err)
+	}
+	executive := func(role string) utils.Principal {
+		return utils.Principal{Kind: utils.PrincipalExecutive, ID: 1, Role: role}
+	}
+	client := func(kind string, scopes ...string) utils.Principal {
+		return utils.Principal{Kind: kind, Role: "admin", Scopes: scopes}
+	}
+	tests := []struct {
+		principal        utils.Principal
+		resource, action string
+		want             bool
+	}{
+		// Roles, with wildcard resources and actions
+		{executive("admin"), "students", "delete", true},
+		{executive("admin"), "anything", "read_deleted", true},
+		{executive("principal"), "students", "update", true},
+		{executive("principal"), "students", "delete", false},
+		{executive("principal"), "teachers", "read", true},
+		{executive("principal"), "teachers", "update", false},
+		{executive("registrar"), "students", "read_deleted", true},
+		{executive("registrar"), "teachers", "read", true},
+		{executive("registrar"), "teachers", "create", false},
+		{executive("registrar"), "classrooms", "read", false},
+		{executive("counselor"), "students", "read", false},
+		{executive("janitor"), "students", "read", false},
+		{executive(""), "students", "read", false},
+		{utils.Principal{Kind: utils.PrincipalClientCert, Role: "principal"}, "students", "read", true},
+
+		// Scopes, ignoring the role
+		{client(utils.PrincipalAPIKey, "students:read"), "students", "read", true},
+		{client(utils.PrincipalAPIKey, "students:read"), "students", "update", false},
+		{client(utils.PrincipalAPIKey, "students:read"), "teachers", "read", false},
+		{client(utils.PrincipalAPIKey, "students:*"), "students", "delete", true},
+		{client(utils.PrincipalOAuthClient, "*:read"), "teachers", "read", true},
+		{client(utils.PrincipalOAuthClient, "*:read"), "teachers", "create", false},
+		{client(utils.PrincipalOAuthClient, "*:*"), "executives", "delete", true},
+		{client(utils.PrincipalOAuthClient, "students", "read", "students:reads"), "students", "read", false},
+		{client(utils.PrincipalAPIKey), "students", "read", false},
+	}
+	for _, tt := range tests {
+		if got := Can(tt.principal, tt.resource, tt.action); got != tt.want {
+			t.Errorf("Can(%s %q %v, %s, %s) = %v, want %v", tt.principal.Kind, tt.principal.Role,
+				tt.principal.Scopes, tt.resource, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestRoleRequiresMFA(t *testing.T) {
+	if err := loadPolicy(t, testPolicy); err != nil {
+		t.Fatal(err)
+	}
+	for role, want := range map[string]bool{"admin": true, "principal": false, "janitor": false, "": false} {
+		if got := RoleRequiresMFA(role); got != want {
+			t.Errorf("RoleRequiresMFA(%q) = %v, want %v", role, got, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/jorge-sader/go-rest-api/pkg/responder"
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+)
+
+// validRequestID limits client supplied request IDs to something safe to log and store.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, stored in the request context and echoed in the
+// X-Request-ID response header. A well-formed X-Request-ID sent by the client (e.g. a gateway) is kept.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			generated, err := utils.RandomHex(16)
+			if err != nil {
+				responder.Error(w, r, "Error generating request id", http.StatusInternalServerError)
+				return
+			}
+			id = generated
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(utils.WithRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,45 @@
+-- Code generated by cmd/gen from internal/models/defs. DO NOT EDIT.
+
+CREATE TABLE IF NOT EXISTS classrooms (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    room_number VARCHAR(50) NOT NULL,
+    building VARCHAR(100) NULL,
+    capacity INT NULL
+);
+
+CREATE TABLE IF NOT EXISTS executives (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(50) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS students (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    classroom_id INT NOT NULL,
+    FOREIGN KEY (classroom_id) REFERENCES classrooms (id)
+);
+
+CREATE TABLE IF NOT EXISTS subjects (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description VARCHAR(1000) NULL,
+    total_hours VARCHAR(50) NULL
+);
+
+CREATE TABLE IF NOT EXISTS teachers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    classroom_id INT NOT NULL,
+    subject_id INT NOT NULL,
+    FOREIGN KEY (classroom_id) REFERENCES classrooms (id),
+    FOREIGN KEY (subject_id) REFERENCES subjects (id)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    executive_id INT NOT NULL,
+    family_id    CHAR(32) NOT NULL,
+    token_hash   CHAR(64) NOT NULL UNIQUE,
+    expires_at   DATETIME NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at   DATETIME NULL,
+    INDEX idx_refresh_tokens_executive (executive_id),
+    INDEX idx_refresh_tokens_family (family_id)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE executives
+    ADD COLUMN IF NOT EXISTS password_changed_at DATETIME NULL,
+    ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    executive_id INT NOT NULL,
+    token_hash   CHAR(64) NOT NULL UNIQUE,
+    expires_at   DATETIME NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at      DATETIME NULL,
+    INDEX idx_password_reset_tokens_executive (executive_id)
+);
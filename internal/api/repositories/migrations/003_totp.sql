@@ -0,0 +1,12 @@
+ALTER TABLE executives
+    ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NULL,
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    executive_id INT NOT NULL,
+    code_hash    CHAR(64) NOT NULL,
+    used_at      DATETIME NULL,
+    INDEX idx_recovery_codes_executive (executive_id)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    username       VARCHAR(255) PRIMARY KEY,
+    failed_count   INT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until   DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor      VARCHAR(255) NOT NULL,
+    action     VARCHAR(64) NOT NULL,
+    resource   VARCHAR(64) NOT NULL,
+    record_id  VARCHAR(64) NULL,
+    changes    JSON NULL,
+    request_id VARCHAR(64) NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_audit_log_actor (actor),
+    INDEX idx_audit_log_resource (resource, record_id),
+    INDEX idx_audit_log_created_at (created_at)
+);
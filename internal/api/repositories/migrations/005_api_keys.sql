@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL UNIQUE,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL,
+    created_by   VARCHAR(255) NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at   DATETIME NULL,
+    last_used_at DATETIME NULL,
+    revoked_at   DATETIME NULL
+);
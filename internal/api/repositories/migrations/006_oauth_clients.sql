@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    client_id   VARCHAR(64) NOT NULL UNIQUE,
+    secret_hash CHAR(64) NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    scopes      TEXT NOT NULL,
+    created_by  VARCHAR(255) NOT NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at  DATETIME NULL
+);
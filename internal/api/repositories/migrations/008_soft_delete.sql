@@ -0,0 +1,7 @@
+ALTER TABLE students ADD COLUMN IF NOT EXISTS deleted_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS idx_students_deleted_at ON students (deleted_at);
+
+ALTER TABLE teachers ADD COLUMN IF NOT EXISTS deleted_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS idx_teachers_deleted_at ON teachers (deleted_at);
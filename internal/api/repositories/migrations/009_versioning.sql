@@ -0,0 +1,3 @@
+ALTER TABLE students ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE teachers ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    principal    VARCHAR(255) NOT NULL,
+    idem_key     VARCHAR(255) NOT NULL,
+    fingerprint  CHAR(64) NOT NULL,
+    status       INT NULL,
+    headers      JSON NULL,
+    body         MEDIUMBLOB NULL,
+    created_at   DATETIME NOT NULL,
+    expires_at   DATETIME NOT NULL,
+    PRIMARY KEY (principal, idem_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1 @@
+ALTER TABLE executives MODIFY COLUMN password VARCHAR(255) NOT NULL DEFAULT '';
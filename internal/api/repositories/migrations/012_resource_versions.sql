@@ -0,0 +1,5 @@
+ALTER TABLE classrooms ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE subjects ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE executives ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
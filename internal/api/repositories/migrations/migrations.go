@@ -0,0 +1,9 @@
+// Package migrations embeds the ordered SQL schema migrations applied at startup.
+// Files are named NNN_description.sql and applied in lexical order exactly once. A file that fails
+// halfway runs again in full, so its statements must be safe to re-run (see sqlconnect.Migrate).
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
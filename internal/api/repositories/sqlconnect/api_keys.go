@@ -0,0 +1,95 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+const selectAPIKey = `SELECT id, name, prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at FROM api_keys`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.CreatedBy, &key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+// CreateAPIKey stores a new API key and returns its ID. Scopes are stored space separated.
+func CreateAPIKey(ctx context.Context, db *sql.DB, key models.APIKey) (int, error) {
+	var expiresAt any
+	if key.ExpiresAt != nil {
+		expiresAt = key.ExpiresAt.UTC()
+	}
+	res, err := db.ExecContext(ctx, `INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, " "), key.CreatedBy, expiresAt)
+	if err != nil {
+		log.Printf("Error creating API key %s: %v", key.Prefix, err)
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// GetAPIKeyByHash fetches an API key by the hash of the full key
+func GetAPIKeyByHash(ctx context.Context, db *sql.DB, keyHash string) (models.APIKey, error) {
+	return scanAPIKey(db.QueryRowContext(ctx, selectAPIKey+` WHERE key_hash = ?`, keyHash))
+}
+
+// ListAPIKeys returns every API key, newest first
+func ListAPIKeys(ctx context.Context, db *sql.DB) ([]models.APIKey, error) {
+	rows, err := db.QueryContext(ctx, selectAPIKey+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchAPIKey records that a key was just used
+func TouchAPIKey(ctx context.Context, db *sql.DB, id int) error {
+	_, err := db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	return err
+}
+
+// RevokeAPIKey revokes an API key. It returns the number of keys revoked (0 if unknown or already revoked).
+func RevokeAPIKey(ctx context.Context, db *sql.DB, id int) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = UTC_TIMESTAMP() WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Printf("Error revoking API key %d: %v", id, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so audit entries for data mutations
+// can be written inside the same transaction as the change they describe.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// InsertAuditEntry appends an entry to the audit log
+func InsertAuditEntry(ctx context.Context, db execer, entry models.AuditEntry) error {
+	var changes any
+	if len(entry.Changes) > 0 {
+		changes = string(entry.Changes)
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO audit_log (actor, action, resource, record_id, changes, request_id) VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.Actor, entry.Action, entry.Resource, nullString(entry.RecordID), changes, nullString(entry.RequestID))
+	if err != nil {
+		log.Printf("Error writing audit entry %s %s/%s: %v", entry.Action, entry.Resource, entry.RecordID, err)
+	}
+	return err
+}
+
+// AuditFilter narrows ListAuditEntries. Zero values are ignored.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Resource string
+	RecordID string
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+	Limit    int
+	Offset   int
+}
+
+// ListAuditEntries returns audit entries matching the filter, newest first
+func ListAuditEntries(ctx context.Context, db *sql.DB, filter AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	for column, value := range map[string]string{
+		"actor":     filter.Actor,
+		"action":    filter.Action,
+		"resource":  filter.Resource,
+		"record_id": filter.RecordID,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := `SELECT id, actor, action, resource, record_id, changes, request_id, created_at FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error querying audit log: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		var recordID, requestID sql.NullString
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Resource, &recordID, &changes, &requestID, &entry.CreatedAt); err != nil {
+			log.Printf("Error scanning audit entry: %v", err)
+			return nil, err
+		}
+		entry.RecordID = recordID.String
+		entry.RequestID = requestID.String
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// nullString maps an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
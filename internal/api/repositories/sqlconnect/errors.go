@@ -0,0 +1,31 @@
+package sqlconnect
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers the handlers translate into client errors.
+const (
+	errBadNull   = 1048 // ER_BAD_NULL_ERROR
+	errDupEntry  = 1062 // ER_DUP_ENTRY
+	errRowIsRef  = 1451 // ER_ROW_IS_REFERENCED_2
+	errNoRefRow  = 1452 // ER_NO_REFERENCED_ROW_2
+	errTruncated = 1366 // ER_TRUNCATED_WRONG_VALUE_FOR_FIELD
+)
+
+// ConstraintViolation reports whether err was caused by data the client sent rather than by the
+// server: a NULL in a NOT NULL column, a duplicate unique value, a dangling foreign key, a delete of
+// a row other rows still reference or a value of the wrong type. It returns the server's message, which names the offending column.
+func ConstraintViolation(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return "", false
+	}
+	switch mysqlErr.Number {
+	case errBadNull, errDupEntry, errRowIsRef, errNoRefRow, errTruncated:
+		return mysqlErr.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,95 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+const selectExecutive = `SELECT id, first_name, last_name, email, username, password, password_changed_at, must_change_password, totp_secret, totp_enabled, totp_last_step, role FROM executives`
+
+// scanExecutive scans a row selected with selectExecutive
+func scanExecutive(row *sql.Row) (models.Executive, error) {
+	var exec models.Executive
+	var changedAt sql.NullTime
+	var totpSecret sql.NullString
+	err := row.Scan(&exec.ID, &exec.FirstName, &exec.LastName, &exec.Email, &exec.Username, &exec.Password, &changedAt, &exec.MustChangePassword,
+		&totpSecret, &exec.TOTPEnabled, &exec.TOTPLastStep, &exec.Role)
+	if err != nil {
+		return models.Executive{}, err
+	}
+	if changedAt.Valid {
+		exec.PasswordChangedAt = &changedAt.Time
+	}
+	exec.TOTPSecret = totpSecret.String
+	return exec, nil
+}
+
+// GetExecutiveByUsername fetches an executive, including the stored password hash, by username
+func GetExecutiveByUsername(ctx context.Context, db *sql.DB, username string) (models.Executive, error) {
+	exec, err := scanExecutive(db.QueryRowContext(ctx, selectExecutive+` WHERE username = ?`, username))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching executive with username %s: %v", username, err)
+	}
+	return exec, err
+}
+
+// GetExecutiveByID fetches an executive, including the stored password hash, by ID
+func GetExecutiveByID(ctx context.Context, db *sql.DB, id int) (models.Executive, error) {
+	exec, err := scanExecutive(db.QueryRowContext(ctx, selectExecutive+` WHERE id = ?`, id))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching executive with id %d: %v", id, err)
+	}
+	return exec, err
+}
+
+// GetExecutiveByEmail fetches an executive, including the stored password hash, by email
+func GetExecutiveByEmail(ctx context.Context, db *sql.DB, email string) (models.Executive, error) {
+	exec, err := scanExecutive(db.QueryRowContext(ctx, selectExecutive+` WHERE email = ?`, email))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching executive with email %s: %v", email, err)
+	}
+	return exec, err
+}
+
+// UpdateExecutivePassword replaces the stored password hash for an executive without
+// changing password_changed_at. It is used to transparently rehash on login.
+func UpdateExecutivePassword(ctx context.Context, db *sql.DB, id int, passwordHash string) error {
+	_, err := db.ExecContext(ctx, `UPDATE executives SET password = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		log.Printf("Error updating password for executive %d: %v", id, err)
+	}
+	return err
+}
+
+// ChangeExecutivePassword sets a new password chosen by the executive, records when it changed
+// and clears any pending forced change.
+func ChangeExecutivePassword(ctx context.Context, tx *sql.Tx, id int, passwordHash string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE executives SET password = ?, password_changed_at = UTC_TIMESTAMP(), must_change_password = FALSE, version = version + 1 WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		log.Printf("Error changing password for executive %d: %v", id, err)
+	}
+	return err
+}
+
+// GetSessionState reports whether an executive has a forced password change pending and whether
+// the session (refresh token family) of an access token is still active, i.e. has a token that was
+// not revoked. Tokens without a session, such as the partial MFA token, are always active.
+func GetSessionState(ctx context.Context, db *sql.DB, id int, session string) (mustChangePassword, active bool, err error) {
+	err = db.QueryRowContext(ctx, `SELECT e.must_change_password,
+		? = '' OR EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.family_id = ? AND t.executive_id = e.id AND t.revoked_at IS NULL)
+		FROM executives e WHERE e.id = ?`, session, session, id).Scan(&mustChangePassword, &active)
+	return mustChangePassword, active, err
+}
+
+// SetMustChangePassword flags (or unflags) an executive as required to change their password
+func SetMustChangePassword(ctx context.Context, db *sql.DB, id int, must bool) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE executives SET must_change_password = ?, version = version + 1 WHERE id = ?`, must, id)
+	if err != nil {
+		log.Printf("Error flagging executive %d for password change: %v", id, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
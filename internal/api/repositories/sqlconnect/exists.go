@@ -0,0 +1,48 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so foreign-key checks see the rows
+// of the transaction that is about to reference them.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ReferenceTables lists the tables other records may point at by id.
+var ReferenceTables = []string{"classrooms", "subjects", "students", "teachers", "executives"}
+
+// RecordExists reports whether table has a row with the given id. Soft-deleted rows do not count.
+func RecordExists(ctx context.Context, db queryer, table string, id any) (bool, error) {
+	if !slices.Contains(ReferenceTables, table) {
+		return false, fmt.Errorf("sqlconnect: %q is not a reference table", table)
+	}
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = ?`
+	if slices.Contains(SoftDeleteTables, table) {
+		query += ` AND deleted_at IS NULL`
+	}
+	query += `)`
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		log.Printf("Error checking %s id %v: %v", table, id, err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// ClassroomIDByRoomNumber returns the id of the classroom with the given room number,
+// or sql.ErrNoRows when there is none.
+func ClassroomIDByRoomNumber(ctx context.Context, db queryer, roomNumber string) (int, error) {
+	var id int
+	err := db.QueryRowContext(ctx, `SELECT id FROM classrooms WHERE room_number = ?`, roomNumber).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error looking up classroom %q: %v", roomNumber, err)
+	}
+	return id, err
+}
@@ -0,0 +1,96 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+// ReserveIdempotencyKey records key as in progress until key.LockedUntil. It returns false when the
+// principal already used the key and it has not expired; an expired entry, or a reservation whose
+// lease lapsed because the request holding it never finished, is replaced.
+func ReserveIdempotencyKey(ctx context.Context, db *sql.DB, key models.IdempotencyKey) (bool, error) {
+	if _, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE principal = ? AND idem_key = ?
+		AND (expires_at <= UTC_TIMESTAMP() OR (status IS NULL AND (locked_until IS NULL OR locked_until <= UTC_TIMESTAMP())))`,
+		key.Principal, key.Key); err != nil {
+		log.Printf("Error clearing expired idempotency key: %v", err)
+		return false, err
+	}
+
+	result, err := db.ExecContext(ctx, `INSERT IGNORE INTO idempotency_keys (principal, idem_key, fingerprint, created_at, expires_at, locked_until) VALUES (?, ?, ?, ?, ?, ?)`,
+		key.Principal, key.Key, key.Fingerprint, key.CreatedAt.UTC(), key.ExpiresAt.UTC(), key.LockedUntil.UTC())
+	if err != nil {
+		log.Printf("Error reserving idempotency key: %v", err)
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	return inserted == 1, err
+}
+
+// GetIdempotencyKey fetches a stored key
+func GetIdempotencyKey(ctx context.Context, db *sql.DB, principal, key string) (models.IdempotencyKey, error) {
+	entry := models.IdempotencyKey{Principal: principal, Key: key}
+	var status sql.NullInt64
+	var lockedUntil sql.NullTime
+	var headers []byte
+	err := db.QueryRowContext(ctx, `SELECT fingerprint, status, headers, body, created_at, expires_at, locked_until FROM idempotency_keys WHERE principal = ? AND idem_key = ?`,
+		principal, key).Scan(&entry.Fingerprint, &status, &headers, &entry.Body, &entry.CreatedAt, &entry.ExpiresAt, &lockedUntil)
+	if err != nil {
+		return models.IdempotencyKey{}, err
+	}
+	entry.Status = int(status.Int64)
+	entry.LockedUntil = lockedUntil.Time
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &entry.Headers); err != nil {
+			return models.IdempotencyKey{}, err
+		}
+	}
+	return entry, nil
+}
+
+// RenewIdempotencyKey extends the lease of a key still in progress
+func RenewIdempotencyKey(ctx context.Context, db *sql.DB, principal, key string, lockedUntil time.Time) error {
+	_, err := db.ExecContext(ctx, `UPDATE idempotency_keys SET locked_until = ? WHERE principal = ? AND idem_key = ? AND status IS NULL`,
+		lockedUntil.UTC(), principal, key)
+	if err != nil {
+		log.Printf("Error renewing idempotency key: %v", err)
+	}
+	return err
+}
+
+// CompleteIdempotencyKey stores the final response for a reserved key
+func CompleteIdempotencyKey(ctx context.Context, db *sql.DB, principal, key string, status int, headers map[string]string, body []byte) error {
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `UPDATE idempotency_keys SET status = ?, headers = ?, body = ?, locked_until = NULL WHERE principal = ? AND idem_key = ?`,
+		status, string(encodedHeaders), body, principal, key)
+	if err != nil {
+		log.Printf("Error storing idempotent response: %v", err)
+	}
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a reserved key so the request can be retried, e.g. after a server error
+func ReleaseIdempotencyKey(ctx context.Context, db *sql.DB, principal, key string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE principal = ? AND idem_key = ?`, principal, key)
+	if err != nil {
+		log.Printf("Error releasing idempotency key: %v", err)
+	}
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes keys past their expiry and returns how many were removed
+func DeleteExpiredIdempotencyKeys(ctx context.Context, db *sql.DB) (int64, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= UTC_TIMESTAMP()`)
+	if err != nil {
+		log.Printf("Error deleting expired idempotency keys: %v", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
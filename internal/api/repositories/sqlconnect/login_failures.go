@@ -0,0 +1,54 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// GetLockedUntil returns when the lockout for username ends, or the zero time if it is not locked
+func GetLockedUntil(ctx context.Context, db *sql.DB, username string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := db.QueryRowContext(ctx, `SELECT locked_until FROM login_failures WHERE username = ?`, username).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	} else if err != nil {
+		log.Printf("Error querying lockout for %s: %v", username, err)
+		return time.Time{}, err
+	}
+	return lockedUntil.Time, nil
+}
+
+// RecordLoginFailure increments the consecutive failure count for username and returns the new count
+func RecordLoginFailure(ctx context.Context, db *sql.DB, username string) (int, error) {
+	_, err := db.ExecContext(ctx, `INSERT INTO login_failures (username, failed_count, last_failed_at) VALUES (?, 1, UTC_TIMESTAMP())
+		ON DUPLICATE KEY UPDATE failed_count = failed_count + 1, last_failed_at = UTC_TIMESTAMP()`, username)
+	if err != nil {
+		log.Printf("Error recording login failure for %s: %v", username, err)
+		return 0, err
+	}
+
+	var count int
+	err = db.QueryRowContext(ctx, `SELECT failed_count FROM login_failures WHERE username = ?`, username).Scan(&count)
+	return count, err
+}
+
+// LockLogin locks username until the given time
+func LockLogin(ctx context.Context, db *sql.DB, username string, until time.Time) error {
+	_, err := db.ExecContext(ctx, `UPDATE login_failures SET locked_until = ? WHERE username = ?`, until.UTC(), username)
+	if err != nil {
+		log.Printf("Error locking login for %s: %v", username, err)
+	}
+	return err
+}
+
+// ClearLoginFailures resets failure tracking for username after a successful login or an admin unlock
+func ClearLoginFailures(ctx context.Context, db *sql.DB, username string) (int64, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM login_failures WHERE username = ?`, username)
+	if err != nil {
+		log.Printf("Error clearing login failures for %s: %v", username, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,107 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/migrations"
+)
+
+// Migrate applies every embedded migration that has not been recorded in schema_migrations yet.
+// Statements are separated by a semicolon at the end of a line. Each file runs in a transaction that
+// also records it, but MySQL and MariaDB commit implicitly on every DDL statement: a file failing
+// halfway keeps the schema changes before the failure and is not recorded, so it runs again in full
+// at the next startup. Migrations must therefore be safe to re-run, with CREATE ... IF NOT EXISTS and
+// ADD COLUMN IF NOT EXISTS (see rerunnable, enforced by the tests).
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		var applied int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, file).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("checking migration %s: %w", file, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		contents, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range splitStatements(string(contents)) {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("applying migration %s: %w", file, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, file); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %s: %w", file, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Applied migration %s", file)
+	}
+	return nil
+}
+
+// splitStatements splits a migration file on semicolons that end a line, skipping empty statements.
+func splitStatements(contents string) []string {
+	var statements []string
+	for _, stmt := range strings.Split(contents, ";\n") {
+		stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// rerunnable reports whether a migration statement can run again on a schema it already changed:
+// tables, indexes and triggers are created IF NOT EXISTS, columns and indexes added IF NOT EXISTS, and other
+// column changes restate the column definition. Statements other than CREATE and ALTER are left to
+// the transaction of their file.
+func rerunnable(stmt string) bool {
+	upper := strings.ToUpper(strings.Join(strings.Fields(stmt), " "))
+	switch {
+	case strings.HasPrefix(upper, "CREATE "):
+		return strings.HasPrefix(upper, "CREATE TABLE IF NOT EXISTS ") ||
+			strings.HasPrefix(upper, "CREATE INDEX IF NOT EXISTS ") ||
+			strings.HasPrefix(upper, "CREATE TRIGGER IF NOT EXISTS ")
+	case strings.HasPrefix(upper, "ALTER TABLE "):
+		for _, clause := range strings.Split(upper, ",") {
+			if strings.Contains(clause, " ADD ") && !strings.Contains(clause, " IF NOT EXISTS ") ||
+				strings.Contains(clause, " DROP ") && !strings.Contains(clause, " IF EXISTS ") ||
+				strings.Contains(clause, " RENAME ") {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
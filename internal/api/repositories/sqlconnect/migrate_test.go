@@ -0,0 +1,57 @@
+package sqlconnect
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/jorge-sader/go-rest-api/internal/api/repositories/migrations"
+)
+
+// TestMigrationsRerunnable checks every embedded migration statement with rerunnable, since a file
+// that fails halfway runs again in full.
+func TestMigrationsRerunnable(t *testing.T) {
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		contents, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range splitStatements(string(contents)) {
+			if !rerunnable(stmt) {
+				t.Errorf("%s: statement cannot be re-run:\n%s", file, stmt)
+			}
+		}
+	}
+}
+
+func TestRerunnable(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"CREATE TABLE IF NOT EXISTS t (id INT)", true},
+		{"CREATE TABLE t (id INT)", false},
+		{"create  index if not exists idx_t_a on t (a)", true},
+		{"CREATE INDEX idx_t_a ON t (a)", false},
+		{"CREATE TRIGGER IF NOT EXISTS t_no_update BEFORE UPDATE ON t FOR EACH ROW SET @x = 1", true},
+		{"CREATE TRIGGER t_no_update BEFORE UPDATE ON t FOR EACH ROW SET @x = 1", false},
+		{"ALTER TABLE t ADD COLUMN IF NOT EXISTS a INT", true},
+		{"ALTER TABLE t\n    ADD COLUMN IF NOT EXISTS a INT,\n    ADD COLUMN IF NOT EXISTS b DECIMAL(10,2)", true},
+		{"ALTER TABLE t ADD COLUMN IF NOT EXISTS a INT, ADD COLUMN b INT", false},
+		{"ALTER TABLE t ADD COLUMN a INT", false},
+		{"ALTER TABLE t ADD INDEX idx_t_a (a)", false},
+		{"ALTER TABLE t DROP COLUMN a", false},
+		{"ALTER TABLE t DROP COLUMN IF EXISTS a", true},
+		{"ALTER TABLE t RENAME COLUMN a TO b", false},
+		{"ALTER TABLE t MODIFY COLUMN a VARCHAR(255) NOT NULL DEFAULT ''", true},
+		{"UPDATE t SET a = 1 WHERE a IS NULL", true},
+	}
+	for _, tt := range tests {
+		if got := rerunnable(tt.stmt); got != tt.want {
+			t.Errorf("rerunnable(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+const selectOAuthClient = `SELECT id, client_id, secret_hash, name, scopes, created_by, created_at, revoked_at FROM oauth_clients`
+
+func scanOAuthClient(row scanner) (models.OAuthClient, error) {
+	var client models.OAuthClient
+	var scopes string
+	var revokedAt sql.NullTime
+	err := row.Scan(&client.ID, &client.ClientID, &client.SecretHash, &client.Name, &scopes, &client.CreatedBy, &client.CreatedAt, &revokedAt)
+	if err != nil {
+		return models.OAuthClient{}, err
+	}
+	client.Scopes = strings.Fields(scopes)
+	if revokedAt.Valid {
+		client.RevokedAt = &revokedAt.Time
+	}
+	return client, nil
+}
+
+// CreateOAuthClient registers a new OAuth2 client and returns its row ID
+func CreateOAuthClient(ctx context.Context, db *sql.DB, client models.OAuthClient) (int, error) {
+	res, err := db.ExecContext(ctx, `INSERT INTO oauth_clients (client_id, secret_hash, name, scopes, created_by) VALUES (?, ?, ?, ?, ?)`,
+		client.ClientID, client.SecretHash, client.Name, strings.Join(client.Scopes, " "), client.CreatedBy)
+	if err != nil {
+		log.Printf("Error creating OAuth client %s: %v", client.ClientID, err)
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// GetOAuthClientByClientID fetches a registered client by its public client_id
+func GetOAuthClientByClientID(ctx context.Context, db *sql.DB, clientID string) (models.OAuthClient, error) {
+	return scanOAuthClient(db.QueryRowContext(ctx, selectOAuthClient+` WHERE client_id = ?`, clientID))
+}
+
+// ListOAuthClients returns every registered client, newest first
+func ListOAuthClients(ctx context.Context, db *sql.DB) ([]models.OAuthClient, error) {
+	rows, err := db.QueryContext(ctx, selectOAuthClient+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := make([]models.OAuthClient, 0)
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// RevokeOAuthClient revokes a client so it can no longer obtain tokens
+func RevokeOAuthClient(ctx context.Context, db *sql.DB, id int) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE oauth_clients SET revoked_at = UTC_TIMESTAMP() WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Printf("Error revoking OAuth client %d: %v", id, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,52 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrResetTokenInvalid is returned for unknown, used or expired password reset tokens.
+var ErrResetTokenInvalid = errors.New("invalid or expired reset token")
+
+// CreatePasswordResetToken stores the hash of a single-use reset token
+func CreatePasswordResetToken(ctx context.Context, db *sql.DB, executiveID int, tokenHash string, expiresAt time.Time) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO password_reset_tokens (executive_id, token_hash, expires_at) VALUES (?, ?, ?)`,
+		executiveID, tokenHash, expiresAt.UTC())
+	if err != nil {
+		log.Printf("Error storing password reset token for executive %d: %v", executiveID, err)
+	}
+	return err
+}
+
+// ConsumePasswordResetToken marks a valid reset token as used inside tx and returns the executive it belongs to.
+// The conditional UPDATE guarantees a token can only be consumed once, even under concurrent requests.
+func ConsumePasswordResetToken(ctx context.Context, tx *sql.Tx, tokenHash string) (int, error) {
+	var id int64
+	var executiveID int
+	err := tx.QueryRowContext(ctx, `SELECT id, executive_id FROM password_reset_tokens WHERE token_hash = ? AND used_at IS NULL AND expires_at > UTC_TIMESTAMP()`, tokenHash).
+		Scan(&id, &executiveID)
+	if err == sql.ErrNoRows {
+		return 0, ErrResetTokenInvalid
+	} else if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE password_reset_tokens SET used_at = UTC_TIMESTAMP() WHERE id = ? AND used_at IS NULL`, id)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, ErrResetTokenInvalid
+	}
+
+	// Any other outstanding reset tokens for this executive are no longer needed
+	if _, err := tx.ExecContext(ctx, `UPDATE password_reset_tokens SET used_at = UTC_TIMESTAMP() WHERE executive_id = ? AND used_at IS NULL`, executiveID); err != nil {
+		return 0, err
+	}
+	return executiveID, nil
+}
@@ -0,0 +1,88 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+// ErrRefreshTokenReused is returned when an already rotated or revoked refresh token is presented again.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// CreateRefreshToken stores the hash of a newly issued refresh token
+func CreateRefreshToken(ctx context.Context, db *sql.DB, executiveID int, familyID, tokenHash string, expiresAt time.Time) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO refresh_tokens (executive_id, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		executiveID, familyID, tokenHash, expiresAt.UTC())
+	if err != nil {
+		log.Printf("Error storing refresh token for executive %d: %v", executiveID, err)
+	}
+	return err
+}
+
+// GetRefreshTokenByHash fetches a refresh token record by its hash
+func GetRefreshTokenByHash(ctx context.Context, db *sql.DB, tokenHash string) (models.RefreshToken, error) {
+	var rt models.RefreshToken
+	var revokedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `SELECT id, executive_id, family_id, token_hash, expires_at, created_at, revoked_at FROM refresh_tokens WHERE token_hash = ?`, tokenHash).
+		Scan(&rt.ID, &rt.ExecutiveID, &rt.FamilyID, &rt.TokenHash, &rt.ExpiresAt, &rt.CreatedAt, &revokedAt)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	if revokedAt.Valid {
+		rt.RevokedAt = &revokedAt.Time
+	}
+	return rt, nil
+}
+
+// RotateRefreshToken revokes current and stores its successor in the same family in one transaction.
+// If current was already revoked (a replay), ErrRefreshTokenReused is returned and nothing is stored.
+func RotateRefreshToken(ctx context.Context, db *sql.DB, current models.RefreshToken, newHash string, expiresAt time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = UTC_TIMESTAMP() WHERE id = ? AND revoked_at IS NULL`, current.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO refresh_tokens (executive_id, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		current.ExecutiveID, current.FamilyID, newHash, expiresAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revokes every token descended from the same login
+func RevokeRefreshTokenFamily(ctx context.Context, db *sql.DB, familyID string) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = UTC_TIMESTAMP() WHERE family_id = ? AND revoked_at IS NULL`, familyID)
+	if err != nil {
+		log.Printf("Error revoking refresh token family %s: %v", familyID, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeExecutiveRefreshTokens revokes every active session of an executive but the token family
+// named by except, the caller's own session; an empty except revokes them all.
+func RevokeExecutiveRefreshTokens(ctx context.Context, db execer, executiveID int, except string) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = UTC_TIMESTAMP() WHERE executive_id = ? AND family_id <> ? AND revoked_at IS NULL`, executiveID, except)
+	if err != nil {
+		log.Printf("Error revoking sessions for executive %d: %v", executiveID, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
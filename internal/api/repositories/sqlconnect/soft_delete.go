@@ -0,0 +1,51 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jorge-sader/go-rest-api/internal/models"
+)
+
+// SoftDeleteTables lists the tables whose rows are soft deleted through a deleted_at column.
+var SoftDeleteTables = []string{"students", "teachers"}
+
+// PurgeDeleted permanently removes rows of table soft deleted before cutoff and records the purge
+// in the audit log within the same transaction. It returns the number of rows removed.
+func PurgeDeleted(ctx context.Context, db *sql.DB, table string, cutoff time.Time) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff.UTC())
+	if err != nil {
+		log.Printf("Error purging %s: %v", table, err)
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+
+	changes, _ := json.Marshal(map[string]any{
+		"purged":         purged,
+		"deleted_before": cutoff.UTC(),
+	})
+	if err := InsertAuditEntry(ctx, tx, models.AuditEntry{
+		Actor:    "system",
+		Action:   "purge",
+		Resource: table,
+		Changes:  changes,
+	}); err != nil {
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
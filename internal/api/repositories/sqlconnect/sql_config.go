@@ -16,7 +16,8 @@ func ConnectDB() (*sql.DB, error) {
 	port := os.Getenv("DB_PORT")
 	dbName := os.Getenv("DB_NAME")
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, password, host, port, dbName)
+	// parseTime lets DATETIME columns scan into time.Time; all timestamps are stored in UTC
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=UTC", user, password, host, port, dbName)
 
 	fmt.Println("Connecting to database...")
 	db, err := sql.Open("mysql", dsn)
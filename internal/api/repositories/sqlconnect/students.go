@@ -10,7 +10,7 @@ import (
 // GetStudentById fetches a student by ID
 func GetStudentById(db *sql.DB, id int) (models.Student, error) {
 	var student models.Student
-	err := db.QueryRow(`SELECT id, first_name, last_name, email, classroom_id FROM students WHERE id = ?`, id).
+	err := db.QueryRow(`SELECT id, first_name, last_name, email, classroom_id FROM students WHERE id = ? AND deleted_at IS NULL`, id).
 		Scan(&student.ID, &student.FirstName, &student.LastName, &student.Email, &student.ClassroomID)
 	if err != nil {
 		log.Printf("Error fetching student with id %d: %v", id, err)
@@ -22,7 +22,7 @@ func GetStudentById(db *sql.DB, id int) (models.Student, error) {
 // GetStudentByEmail fetches a student by email
 func GetStudentByEmail(db *sql.DB, email string) (models.Student, error) {
 	var student models.Student
-	err := db.QueryRow(`SELECT id, first_name, last_name, email, classroom_id FROM students WHERE email = ?`, email).
+	err := db.QueryRow(`SELECT id, first_name, last_name, email, classroom_id FROM students WHERE email = ? AND deleted_at IS NULL`, email).
 		Scan(&student.ID, &student.FirstName, &student.LastName, &student.Email, &student.ClassroomID)
 	if err != nil {
 		log.Printf("Error fetching student with email %s: %v", email, err)
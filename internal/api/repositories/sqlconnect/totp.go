@@ -0,0 +1,68 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// SetPendingTOTPSecret stores a new, not yet confirmed TOTP secret. Enrolling again replaces
+// an unconfirmed secret but never one that is already enabled.
+func SetPendingTOTPSecret(ctx context.Context, db *sql.DB, executiveID int, secret string) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE executives SET totp_secret = ?, totp_last_step = 0 WHERE id = ? AND totp_enabled = FALSE`, secret, executiveID)
+	if err != nil {
+		log.Printf("Error storing TOTP secret for executive %d: %v", executiveID, err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// EnableTOTP confirms enrollment and replaces the executive's recovery codes in one transaction
+func EnableTOTP(ctx context.Context, db *sql.DB, executiveID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE executives SET totp_enabled = TRUE, totp_last_step = ?, version = version + 1 WHERE id = ?`, step, executiveID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE executive_id = ?`, executiveID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO recovery_codes (executive_id, code_hash) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, hash := range recoveryCodeHashes {
+		if _, err := stmt.ExecContext(ctx, executiveID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP time step.
+// It reports false when the step was already used, which rejects replayed codes.
+func AdvanceTOTPStep(ctx context.Context, db *sql.DB, executiveID int, step int64) (bool, error) {
+	res, err := db.ExecContext(ctx, `UPDATE executives SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`, step, executiveID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. It reports false if no such code exists.
+func ConsumeRecoveryCode(ctx context.Context, db *sql.DB, executiveID int, codeHash string) (bool, error) {
+	res, err := db.ExecContext(ctx, `UPDATE recovery_codes SET used_at = UTC_TIMESTAMP() WHERE executive_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1`, executiveID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -2,54 +2,172 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/jorge-sader/go-rest-api/internal/api/handlers"
+	"github.com/jorge-sader/go-rest-api/internal/api/middlewares"
+	"github.com/jorge-sader/go-rest-api/pkg/openapi"
 )
 
 func Router() *http.ServeMux {
 	mux := http.NewServeMux()
+	doc := handlers.NewOpenAPIDocument()
+	api := &routes{mux: mux, doc: doc, described: make(map[string]bool)}
 
 	// Routes
-	mux.HandleFunc("/", handlers.RootHandler)
+	// Students, teachers, classrooms, subjects and executives are served by the generic CRUD
+	// handlers of handlers.Resource, generated from internal/models/defs with registerResources.
+	// Deletes of students and teachers are soft deletes: rows get a deleted_at timestamp, are hidden
+	// from every query unless ?include_deleted=true is allowed, and can be restored until purged.
+	// Every resource route is guarded by middlewares.Authorize, which checks the caller's role
+	// against the RBAC policy loaded at startup. Only "/", login, the /auth/ token endpoints,
+	// the OAuth2 token, introspection and JWKS endpoints and the API documentation are public.
+	// POST and PATCH routes honor Idempotency-Key (see middlewares.Idempotent), except the
+	// authentication and credential endpoints, whose responses are never stored and which reject it.
+	// Routes are registered through api, which also adds them to the OpenAPI document.
+	api.HandleFunc("/", handlers.RootHandler)
+	registerResources(api)
 
 	// TEACHERS
 	// INFO: I'm knowingly using pre Go 1.22 routing method for teachers as lots of legacy code still uses it.
-	mux.HandleFunc("/teachers/", handlers.TeachersHandler)
+	teachers := resource("teachers")
+	api.describe(handlers.Teachers)
+	api.Handle("/teachers/", teachers(http.HandlerFunc(handlers.TeachersHandler)))
+	api.Handle("GET /teachers/export", teachers(http.HandlerFunc(handlers.ExportTeachersHandler)))
+	api.Handle("POST /teachers/import", importer("teachers")(http.HandlerFunc(handlers.ImportTeachersHandler)))
+	api.Handle("POST /teachers/{id}/restore",
+		middlewares.AuthorizeAction("teachers", "restore")(middlewares.Idempotent(http.HandlerFunc(handlers.Teachers.Restore))))
 
 	//STUDENTS
-	mux.HandleFunc("GET /students/", handlers.GetManyStudentsHandler)
-	mux.HandleFunc("GET /students/{id}", handlers.GetOneStudentHandler)
-	mux.HandleFunc("POST /students/", handlers.AddManyStudentsHandler)
-	mux.HandleFunc("PATCH /students/", handlers.PatchManyStudentsHandler)
-	mux.HandleFunc("PATCH /students/{id}", handlers.PatchOneStudentHandler)
-	mux.HandleFunc("DELETE /students/", handlers.DeleteManyStudentsHandler)
-	mux.HandleFunc("DELETE /students/{id}", handlers.DeleteOneStudentHandler)
+	api.Handle("GET /students/export", resource("students")(http.HandlerFunc(handlers.ExportStudentsHandler)))
+	api.Handle("POST /students/import", importer("students")(http.HandlerFunc(handlers.ImportStudentsHandler)))
 
 	//EXECS
-	// mux.HandleFunc("GET /executives/", handlers.GetManyExecutivesHandler)
-	// mux.HandleFunc("GET /executives/{id}", handlers.GetOneExecutiveHandler)
-	// mux.HandleFunc("POST /executives/", handlers.AddManyExecutivesHandler)
-	// mux.HandleFunc("PATCH /executives/", handlers.PatchManyExecutivesHandler)
-	// mux.HandleFunc("PATCH /executives/{id}", handlers.PatchOneExecutiveHandler)
-	// mux.HandleFunc("DELETE /executives/", handlers.DeleteManyExecutivesHandler)
-	// mux.HandleFunc("DELETE /executives/{id}", handlers.DeleteOneExecutiveHandler)
-
-	// CLASSROOMS
-	// mux.HandleFunc("GET /classrooms/", handlers.GetManyClassroomsHandler)
-	// mux.HandleFunc("GET /classrooms/{id}", handlers.GetOneClassroomHandler)
-	// mux.HandleFunc("POST /classrooms/", handlers.AddManyClassroomsHandler)
-	// mux.HandleFunc("PATCH /classrooms/", handlers.PatchManyClassroomsHandler)
-	// mux.HandleFunc("PATCH /classrooms/{id}", handlers.PatchOneClassroomHandler)
-	// mux.HandleFunc("DELETE /classrooms/", handlers.DeleteManyClassroomHandler)
-	// mux.HandleFunc("DELETE /classrooms/{id}", handlers.DeleteOneClassroomHandler)
-
-	// SUBJECTS
-	// mux.HandleFunc("GET /subjects/", handlers.GetManySubjectsHandler)
-	// mux.HandleFunc("GET /subjects/{id}", handlers.GetOneSubjectHandler)
-	// mux.HandleFunc("POST /subjects/", handlers.AddManySubjectsHandler)
-	// mux.HandleFunc("PATCH /subjects/", handlers.PatchManySubjectsHandler)
-	// mux.HandleFunc("PATCH /subjects/{id}", handlers.PatchOneSubjectHandler)
-	// mux.HandleFunc("DELETE /subjects/", handlers.DeleteManySubjectHandler)
-	// mux.HandleFunc("DELETE /subjects/{id}", handlers.DeleteOneSubjectHandler)
+	api.Handle("POST /executives/login", credentials(handlers.LoginHandler))
+	executives := middlewares.Authorize("executives")
+	api.Handle("DELETE /executives/{id}/sessions", executives(http.HandlerFunc(handlers.RevokeExecutiveSessionsHandler)))
+	api.Handle("POST /executives/{id}/force-password-change",
+		middlewares.AuthorizeAction("executives", "force_password_change")(middlewares.Idempotent(http.HandlerFunc(handlers.ForcePasswordChangeHandler))))
+	api.Handle("POST /executives/{id}/unlock",
+		middlewares.AuthorizeAction("executives", "unlock")(middlewares.Idempotent(http.HandlerFunc(handlers.UnlockExecutiveHandler))))
+
+	// API KEYS
+	apiKeys := middlewares.Authorize("api_keys")
+	api.Handle("GET /api-keys/", apiKeys(http.HandlerFunc(handlers.ListAPIKeysHandler)))
+	api.Handle("POST /api-keys/", apiKeys(credentials(handlers.CreateAPIKeyHandler)))
+	api.Handle("DELETE /api-keys/{id}", apiKeys(http.HandlerFunc(handlers.RevokeAPIKeyHandler)))
+
+	// OAUTH2
+	// Client-credentials tokens are RS256 JWTs verifiable offline against the published JWKS.
+	api.Handle("POST /oauth/token", credentials(handlers.TokenHandler))
+	api.Handle("POST /oauth/introspect", credentials(handlers.IntrospectHandler))
+	api.HandleFunc("GET /.well-known/jwks.json", handlers.JWKSHandler)
+	oauthClients := middlewares.Authorize("oauth_clients")
+	api.Handle("GET /oauth/clients/", oauthClients(http.HandlerFunc(handlers.ListOAuthClientsHandler)))
+	api.Handle("POST /oauth/clients/", oauthClients(credentials(handlers.CreateOAuthClientHandler)))
+	api.Handle("DELETE /oauth/clients/{id}", oauthClients(http.HandlerFunc(handlers.RevokeOAuthClientHandler)))
+
+	// AUDIT
+	api.Handle("GET /audit", middlewares.Authorize("audit")(http.HandlerFunc(handlers.GetAuditLogHandler)))
+
+	// INTEGRATIONS
+	// Integration endpoints demand a verified client certificate (mutual TLS) on top of authorization.
+	// With MTLS_MODE=required, so do the resource routes when called with the integration role.
+	api.Handle("GET /integrations/whoami",
+		middlewares.RequireClientCert(middlewares.Authorize("integrations")(http.HandlerFunc(handlers.WhoAmIHandler))))
+
+	// AUTH
+	api.Handle("POST /auth/refresh", credentials(handlers.RefreshHandler))
+	api.Handle("POST /auth/logout", credentials(handlers.LogoutHandler))
+	api.Handle("POST /auth/forgot-password", credentials(handlers.ForgotPasswordHandler))
+	api.Handle("POST /auth/reset-password", credentials(handlers.ResetPasswordHandler))
+	api.Handle("POST /auth/change-password", credentials(handlers.ChangePasswordHandler))
+	api.Handle("POST /auth/2fa/enroll", credentials(handlers.EnrollTOTPHandler))
+	api.Handle("POST /auth/2fa/confirm", credentials(handlers.ConfirmTOTPHandler))
+	api.Handle("POST /auth/2fa/verify", credentials(handlers.VerifyTOTPHandler))
+
+	// DOCS
+	// The OpenAPI document describes every route registered above; /docs/ is a page browsing it.
+	mux.HandleFunc("GET /openapi.json", handlers.OpenAPIHandler(doc))
+	mux.Handle("GET /docs/", handlers.DocsHandler())
 	return mux
 }
+
+// resource guards the routes of a data resource: callers need the RBAC permission for the request
+// method, integrations may need a client certificate, and POST/PATCH requests honor Idempotency-Key.
+func resource(name string) func(http.Handler) http.Handler {
+	authorize := middlewares.Authorize(name)
+	return func(next http.Handler) http.Handler {
+		return authorize(middlewares.RequireClientCertForIntegrations(middlewares.Idempotent(next)))
+	}
+}
+
+// credentials guards the authentication and credential endpoints, which reject Idempotency-Key.
+func credentials(handler http.HandlerFunc) http.Handler {
+	return middlewares.RejectIdempotencyKey(handler)
+}
+
+// registrable is a set of routes served by the generic CRUD handlers, such as handlers.Students.
+type registrable interface {
+	Name() string
+	Routes() []handlers.Route
+}
+
+// register adds the routes of a resource to api, guarded like any other resource route.
+// Routes with an Action of their own, such as restore, need that RBAC permission instead and
+// honor Idempotency-Key too.
+func register(api *routes, res registrable) {
+	api.describe(res)
+	guard := resource(res.Name())
+	for _, route := range res.Routes() {
+		if route.Action != "" {
+			api.Handle(route.Pattern, middlewares.AuthorizeAction(res.Name(), route.Action)(middlewares.Idempotent(route.Handler)))
+			continue
+		}
+		api.Handle(route.Pattern, guard(route.Handler))
+	}
+}
+
+// routes registers handlers on mux and adds them to the OpenAPI document, described by the
+// Route.Doc of resources or by handlers.Operations. Patterns without a method, such as "/", match
+// any request and are left out.
+type routes struct {
+	mux       *http.ServeMux
+	doc       *openapi.Document
+	described map[string]bool
+}
+
+func (api *routes) Handle(pattern string, handler http.Handler) {
+	api.mux.Handle(pattern, handler)
+	if !strings.Contains(pattern, " ") || api.described[pattern] {
+		return
+	}
+	op, ok := handlers.Operations[pattern]
+	if !ok {
+		panic("router: no OpenAPI description of " + pattern + " in handlers.Operations")
+	}
+	api.doc.Add(pattern, op)
+}
+
+func (api *routes) HandleFunc(pattern string, handler http.HandlerFunc) {
+	api.Handle(pattern, handler)
+}
+
+// describe adds the routes of res to the document. Resources served by legacy handlers, such as
+// teachers, are described this way without registering their routes.
+func (api *routes) describe(res registrable) {
+	for _, route := range res.Routes() {
+		api.doc.Add(route.Pattern, route.Doc)
+		api.described[route.Pattern] = true
+	}
+}
+
+// importer guards spreadsheet imports, which create records. Idempotent replay fingerprints the
+// upload and stores only the import result; ?dry_run=true lets clients check a file before committing it.
+func importer(name string) func(http.Handler) http.Handler {
+	authorize := middlewares.AuthorizeAction(name, "create")
+	idempotent := middlewares.IdempotentUpTo(handlers.MaxImportSize)
+	return func(next http.Handler) http.Handler {
+		return authorize(middlewares.RequireClientCertForIntegrations(idempotent(next)))
+	}
+}
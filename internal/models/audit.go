@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is an append-only record of a security event or data mutation.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Resource  string          `json:"resource"`
+	RecordID  string          `json:"record_id,omitempty"`
+	Changes   json.RawMessage `json:"changes,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey is the stored outcome of a POST or PATCH sent with an Idempotency-Key header.
+// Status is zero while the original request is still being processed, which holds the key until
+// LockedUntil; the lease is renewed as long as the request runs, so it only lapses when the process
+// handling it died.
+type IdempotencyKey struct {
+	Principal   string
+	Key         string
+	Fingerprint string
+	Status      int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LockedUntil time.Time
+}
@@ -1,5 +1,9 @@
+// The CRUD models (students, teachers, classrooms, subjects, executives) are generated from
+// their definitions in defs/ together with their DDL, resources and routes; see cmd/gen.
 package models
 
+//go:generate go run github.com/jorge-sader/go-rest-api/cmd/gen
+
 // Model defines methods for providing sortable and filterable fields.
 type Model interface {
 	// SortableFields returns a map of query parameter names to database column names for sorting.
@@ -8,3 +12,16 @@ type Model interface {
 	// FilterableFields returns a map of query parameter names to database column names for filtering.
 	FilterableFields() map[string]string
 }
+
+// SoftDeletable is implemented by models whose rows are marked deleted rather than removed.
+// Queries exclude rows with a non-NULL DeletedAtColumn unless deleted rows are explicitly requested.
+type SoftDeletable interface {
+	Model
+	DeletedAtColumn() string
+}
+
+// Versioned is implemented by models carrying an optimistic concurrency version,
+// incremented on every write and exposed to clients as the record's ETag.
+type Versioned interface {
+	RecordVersion() int
+}
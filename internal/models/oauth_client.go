@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// OAuthClient is a partner system registered for the OAuth2 client_credentials grant.
+// Scopes lists the most a client may request; only the hash of its secret is stored.
+type OAuthClient struct {
+	ID         int        `json:"id"`
+	ClientID   string     `json:"client_id"`
+	SecretHash string     `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RefreshToken is a server-side record of an issued refresh token. Only the hash is stored.
+// Tokens rotated from the same login share a FamilyID so a replayed token can revoke them all.
+type RefreshToken struct {
+	ID          int64
+	ExecutiveID int
+	FamilyID    string
+	TokenHash   string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	RevokedAt   *time.Time
+}
@@ -0,0 +1,253 @@
+// Package codec encodes response bodies and decodes request bodies in the media types the API speaks:
+// JSON (the default), XML, MessagePack and CBOR.
+//
+// Every format carries the same document as JSON: values are encoded through their JSON representation,
+// so field names, omitempty and custom marshalers behave the same whatever the client asked for, and
+// request bodies are transcoded to JSON before being decoded with encoding/json. Responses pick their
+// codec from the Accept header with Negotiate, requests from their Content-Type with ForContentType.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec reads and writes one media type.
+type Codec interface {
+	// MediaType is the Content-Type of the bodies the codec writes.
+	MediaType() string
+	// Encode writes v to w.
+	Encode(w io.Writer, v any) error
+	// Transcode reads a request body in this format and returns it as JSON. v is the value the JSON
+	// will be decoded into, or Items; formats without types of their own (XML) use it to tell numbers
+	// from strings.
+	Transcode(r io.Reader, v any) (io.Reader, error)
+}
+
+// Items is passed to Transcode for a list body whose items have different types: the i-th item
+// has the type of Items[i], and items past the end of Items are untyped.
+type Items []any
+
+// Registered codecs, in order of preference when the Accept header does not decide.
+var (
+	JSON        Codec = jsonCodec{}
+	XML         Codec = xmlCodec{}
+	MessagePack Codec = msgpackCodec{}
+	CBOR        Codec = cborCodec{}
+)
+
+var codecs = []Codec{JSON, XML, MessagePack, CBOR}
+
+// aliases maps every media type a codec answers to, including unregistered variants still in use.
+var aliases = map[string]Codec{
+	"application/json":        JSON,
+	"application/xml":         XML,
+	"text/xml":                XML,
+	"application/vnd.msgpack": MessagePack,
+	"application/msgpack":     MessagePack,
+	"application/x-msgpack":   MessagePack,
+	"application/cbor":        CBOR,
+}
+
+// MediaTypes lists the media types of the registered codecs, for error messages and documentation.
+func MediaTypes() []string {
+	types := make([]string, len(codecs))
+	for i, c := range codecs {
+		types[i] = c.MediaType()
+	}
+	return types
+}
+
+// ForContentType returns the codec reading a request body of the given Content-Type.
+// A missing Content-Type is read as JSON; ok is false when no codec reads the media type.
+func ForContentType(contentType string) (c Codec, ok bool) {
+	if contentType == "" {
+		return JSON, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	c, ok = aliases[mediaType]
+	return c, ok
+}
+
+// Negotiate picks the codec of the response to a request with the given Accept header (RFC 9110
+// section 12.5.1): the acceptable codec with the highest quality, with ties going to the most
+// specific media range and then to the order of preference. An empty header accepts JSON.
+// ok is false when none of the codecs is acceptable.
+func Negotiate(accept string) (c Codec, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, true
+	}
+
+	best, bestQ := Codec(nil), 0.0
+	for _, candidate := range codecs {
+		if q := quality(accept, candidate); q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best, best != nil
+}
+
+// quality returns the weight the Accept header gives to c, taken from the most specific media range
+// matching one of its media types. Ranges that cannot be parsed are ignored.
+func quality(accept string, c Codec) float64 {
+	q, specificity := 0.0, -1
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		s := matches(mediaType, c)
+		if s <= specificity {
+			continue
+		}
+		weight := 1.0
+		if value, ok := params["q"]; ok {
+			if weight, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		q, specificity = weight, s
+	}
+	return q
+}
+
+// matches reports how specifically mediaRange matches c: 2 for one of its media types, 1 for a
+// type/* range, 0 for */*, and -1 when it does not match at all.
+func matches(mediaRange string, c Codec) int {
+	if mediaRange == "*/*" {
+		return 0
+	}
+	for mediaType, alias := range aliases {
+		if alias != c {
+			continue
+		}
+		if mediaType == mediaRange {
+			return 2
+		}
+		if major, _, _ := strings.Cut(mediaType, "/"); mediaRange == major+"/*" {
+			return 1
+		}
+	}
+	return -1
+}
+
+// generic returns the JSON representation of v as plain values: map[string]any, []any, string,
+// int64, float64, bool and nil. Maps lose their key order, so the binary codecs sort their keys.
+func generic(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(value), nil
+}
+
+// normalizeNumbers replaces the json.Numbers of value with int64 or, failing that, float64.
+func normalizeNumbers(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, v := range value {
+			value[key] = normalizeNumbers(v)
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = normalizeNumbers(v)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+	}
+	return value
+}
+
+// toJSON marshals a value decoded by one of the binary codecs back to JSON.
+func toJSON(value any) (io.Reader, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// jsonCodec is encoding/json, as the handlers have always written it.
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Transcode(r io.Reader, _ any) (io.Reader, error) {
+	return r, nil
+}
+
+// msgpackCodec writes MessagePack with sorted map keys, so that equal documents encode to equal
+// bytes and collection ETags stay stable.
+type msgpackCodec struct{}
+
+func (msgpackCodec) MediaType() string { return "application/vnd.msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, v any) error {
+	value, err := generic(v)
+	if err != nil {
+		return err
+	}
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+	return encoder.Encode(value)
+}
+
+func (msgpackCodec) Transcode(r io.Reader, _ any) (io.Reader, error) {
+	var value any
+	if err := msgpack.NewDecoder(r).Decode(&value); err != nil {
+		return nil, err
+	}
+	return toJSON(value)
+}
+
+// cborCodec writes CBOR in the core deterministic encoding of RFC 8949 section 4.2.1.
+type cborCodec struct{}
+
+var (
+	cborEncMode, _ = cbor.CoreDetEncOptions().EncMode()
+	cborDecMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeFor[map[string]any]()}.DecMode()
+)
+
+func (cborCodec) MediaType() string { return "application/cbor" }
+
+func (cborCodec) Encode(w io.Writer, v any) error {
+	value, err := generic(v)
+	if err != nil {
+		return err
+	}
+	return cborEncMode.NewEncoder(w).Encode(value)
+}
+
+func (cborCodec) Transcode(r io.Reader, _ any) (io.Reader, error) {
+	var value any
+	if err := cborDecMode.NewDecoder(r).Decode(&value); err != nil {
+		return nil, err
+	}
+	return toJSON(value)
+}
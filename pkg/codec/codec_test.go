@@ -0,0 +1,229 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   Codec // nil when nothing is acceptable
+	}{
+		{"", JSON},
+		{"application/json", JSON},
+		{"application/xml", XML},
+		{"text/xml", XML},
+		{"application/x-msgpack", MessagePack},
+		{"application/cbor", CBOR},
+		{"application/json; charset=utf-8", JSON},
+
+		// Quality values
+		{"application/json;q=0.5, application/xml", XML},
+		{"application/json;q=0.9, application/cbor;q=0.95", CBOR},
+		{"application/xml;q=0.5, application/json;q=0.5", JSON},
+		{"application/json;q=0", nil},
+		{"application/json;q=0, */*", XML},
+		{"application/json;q=0, application/xml;q=0, application/vnd.msgpack;q=0, application/cbor;q=0", nil},
+		{"application/xml;q=bad, application/cbor;q=0.1", CBOR},
+
+		// Wildcards: ties go to the order of preference, specific ranges override broad ones
+		{"*/*", JSON},
+		{"application/*", JSON},
+		{"text/*", XML},
+		{"text/*, application/*;q=0.5", XML},
+		{"*/*;q=0.1, application/cbor", CBOR},
+		{"application/*;q=0.2, application/msgpack;q=0.3", MessagePack},
+		{"application/*, application/json;q=0", XML},
+
+		// Nothing acceptable
+		{"text/html", nil},
+		{"image/*", nil},
+		{"not a media range", nil},
+		{"text/html, not a media range, application/xml;q=0.4", XML},
+	}
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.accept)
+		if ok != (tt.want != nil) || got != tt.want {
+			t.Errorf("Negotiate(%q) = %v, %v, want %v", tt.accept, got, ok, tt.want)
+		}
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Codec
+	}{
+		{"", JSON},
+		{"application/json", JSON},
+		{"application/json; charset=utf-8", JSON},
+		{"text/xml; charset=utf-8", XML},
+		{"application/msgpack", MessagePack},
+		{"application/cbor", CBOR},
+		{"text/plain", nil},
+		{"application/merge-patch+json", nil},
+		{";;", nil},
+	}
+	for _, tt := range tests {
+		got, ok := ForContentType(tt.contentType)
+		if ok != (tt.want != nil) || got != tt.want {
+			t.Errorf("ForContentType(%q) = %v, %v, want %v", tt.contentType, got, ok, tt.want)
+		}
+	}
+}
+
+type xmlRecord struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Active    bool       `json:"active"`
+	Score     float64    `json:"score"`
+	Tags      []string   `json:"tags"`
+	Joined    time.Time  `json:"joined"`
+	Left      *time.Time `json:"left"`
+	Extra     any        `json:"extra"`
+	Ignored   string     `json:"-"`
+	Untouched string     `json:"untouched,omitempty"`
+}
+
+func transcode(t *testing.T, c Codec, body string, v any) any {
+	t.Helper()
+	r, err := c.Transcode(strings.NewReader(body), v)
+	if err != nil {
+		t.Fatalf("Transcode(%s) error = %v", body, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		t.Fatalf("Transcode(%s) = %s, not JSON: %v", body, data, err)
+	}
+	return value
+}
+
+func TestXMLTranscode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		v    any
+		want string
+	}{
+		{
+			name: "typed fields",
+			body: `<student><id>12</id><name>007</name><active>true</active><score>9.5</score>` +
+				`<tags><item>1</item><item>true</item></tags><joined>2024-01-02T03:04:05Z</joined><left nil="true"/></student>`,
+			v:    &xmlRecord{},
+			want: `{"id": 12, "name": "007", "active": true, "score": 9.5, "tags": ["1", "true"], "joined": "2024-01-02T03:04:05Z", "left": null}`,
+		},
+		{
+			name: "wrong type left as a string",
+			body: `<student><id>twelve</id></student>`,
+			v:    &xmlRecord{},
+			want: `{"id": "twelve"}`,
+		},
+		{
+			name: "untyped and unknown fields are guessed",
+			body: `<student><extra><a>1</a><b>false</b><c>x</c></extra><unknown>-3</unknown></student>`,
+			v:    &xmlRecord{},
+			want: `{"extra": {"a": 1, "b": false, "c": "x"}, "unknown": -3}`,
+		},
+		{
+			name: "untyped list",
+			body: `<list><item>1</item><item>one</item><item><item>2</item></item></list>`,
+			want: `[1, "one", [2]]`,
+		},
+		{
+			name: "map of strings",
+			body: `<fields><name>42</name><city>Lyon</city></fields>`,
+			v:    &map[string]string{},
+			want: `{"name": "42", "city": "Lyon"}`,
+		},
+		{
+			name: "items of different types",
+			body: `<ops><item><value>42</value></item><item><value>42</value></item><item><value>42</value></item></ops>`,
+			v: Items{
+				&struct {
+					Value string `json:"value"`
+				}{},
+				&struct {
+					Value int `json:"value"`
+				}{},
+			},
+			want: `[{"value": "42"}, {"value": 42}, {"value": 42}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transcode(t, XML, tt.body, tt.v)
+			var want any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Transcode() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	for _, body := range []string{"", "<a><b></a>", "just text"} {
+		if _, err := XML.Transcode(strings.NewReader(body), nil); err == nil {
+			t.Errorf("Transcode(%q) succeeded, want an error", body)
+		}
+	}
+}
+
+// TestRoundTrip encodes a response with every codec and reads it back as a request body.
+func TestRoundTrip(t *testing.T) {
+	left := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	record := xmlRecord{
+		ID:      3,
+		Name:    "Ada <Lovelace> & co",
+		Active:  true,
+		Score:   -0.25,
+		Tags:    []string{"a", "12"},
+		Joined:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Left:    &left,
+		Ignored: "dropped",
+	}
+	for _, c := range codecs {
+		t.Run(c.MediaType(), func(t *testing.T) {
+			var body bytes.Buffer
+			if err := c.Encode(&body, record); err != nil {
+				t.Fatal(err)
+			}
+			r, err := c.Transcode(&body, &xmlRecord{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got xmlRecord
+			if err := json.NewDecoder(r).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			want := record
+			want.Ignored = ""
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestXMLEncode(t *testing.T) {
+	var body bytes.Buffer
+	err := XML.Encode(&body, map[string]any{"status": "success", "data": []any{nil, 1}, "1st key": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<response><_1st_key>true</_1st_key><data><item nil="true"></item><item>1</item></data><status>success</status></response>` + "\n"
+	if body.String() != want {
+		t.Errorf("Encode() = %s, want %s", body.String(), want)
+	}
+}
@@ -0,0 +1,324 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// XML documents mirror the JSON ones: objects become elements named after their fields, array items
+// become <item> elements, and null is an empty element with nil="true". The root element of a
+// response is <response>; the root of a request body may have any name.
+//
+//	<response>
+//	  <status>success</status>
+//	  <count>1</count>
+//	  <data><item><id>1</id><first_name>Ada</first_name></item></data>
+//	</response>
+//
+// XML has no types, so request bodies are read against the value they are decoded into: a field
+// declared as a number is sent as a JSON number, a string field as a string. Bodies decoded into
+// untyped values are read against the type they stand for, e.g. a model for the map of a PATCH,
+// or Items for lists of values of different types. Values left untyped are guessed: true/false
+// are booleans, numbers are numbers and anything else is a string.
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string { return "application/xml" }
+
+func (xmlCodec) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if err := writeXMLValue(encoder, decoder, "response"); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeXMLValue reads the next JSON value from decoder and writes it as an element called name.
+// Following the token stream keeps object members in the order encoding/json wrote them.
+func writeXMLValue(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if token == nil {
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "nil"}, Value: "true"}}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		for decoder.More() {
+			child := "item"
+			if token == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = xmlName(key.(string))
+			}
+			if err := writeXMLValue(encoder, decoder, child); err != nil {
+				return err
+			}
+		}
+		// Closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(token))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// xmlName turns an object key into a valid element name, replacing the characters XML names cannot hold.
+func xmlName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		case i == 0 && unicode.IsDigit(r):
+			b.WriteByte('_')
+		default:
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func (xmlCodec) Transcode(r io.Reader, v any) (io.Reader, error) {
+	root, err := parseXML(r)
+	if err != nil {
+		return nil, err
+	}
+	if items, ok := v.(Items); ok {
+		list := make([]any, len(root.children))
+		for i, child := range root.children {
+			var t reflect.Type
+			if i < len(items) && items[i] != nil {
+				t = reflect.TypeOf(items[i])
+			}
+			list[i] = child.value(t)
+		}
+		return toJSON(list)
+	}
+	var t reflect.Type
+	if v != nil {
+		t = reflect.TypeOf(v)
+	}
+	return toJSON(root.value(t))
+}
+
+// xmlNode is an element of a request body.
+type xmlNode struct {
+	name     string
+	null     bool
+	text     string
+	children []*xmlNode
+}
+
+// parseXML reads the root element of an XML document.
+func parseXML(r io.Reader) (*xmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("xml: no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: token.Name.Local}
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "nil" && attr.Value == "true" {
+					node.null = true
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return node, nil
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(token)
+			}
+		}
+	}
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// value converts the element to the generic JSON value that decodes into a t. A nil t stands for
+// an untyped value.
+func (n *xmlNode) value(t reflect.Type) any {
+	if n.null {
+		return nil
+	}
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return n.untyped()
+	}
+	// Types decoding themselves, such as time.Time, are given the text as a JSON string
+	if pt := reflect.PointerTo(t); pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return n.text
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := jsonFields(t)
+		object := make(map[string]any, len(n.children))
+		for _, child := range n.children {
+			object[child.name] = child.value(fields[child.name])
+		}
+		return object
+	case reflect.Map:
+		object := make(map[string]any, len(n.children))
+		for _, child := range n.children {
+			object[child.name] = child.value(t.Elem())
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		// Byte slices are base64 strings in JSON
+		if t.Elem().Kind() == reflect.Uint8 {
+			return strings.TrimSpace(n.text)
+		}
+		list := make([]any, 0, len(n.children))
+		for _, child := range n.children {
+			list = append(list, child.value(t.Elem()))
+		}
+		return list
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(strings.TrimSpace(n.text)); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if number, ok := n.number(); ok {
+			return number
+		}
+	}
+	// Left as a string, a value of the wrong type fails to decode like it would in JSON
+	return n.text
+}
+
+// untyped guesses the value of an element decoded into an interface: elements whose children are
+// all <item> are arrays, other elements with children are objects, and leaves are booleans,
+// numbers or strings.
+func (n *xmlNode) untyped() any {
+	if len(n.children) == 0 {
+		switch strings.TrimSpace(n.text) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		if number, ok := n.number(); ok {
+			return number
+		}
+		return n.text
+	}
+
+	list := true
+	for _, child := range n.children {
+		list = list && child.name == "item"
+	}
+	if list {
+		return n.value(reflect.TypeFor[[]any]())
+	}
+	return n.value(reflect.TypeFor[map[string]any]())
+}
+
+// number returns the text of a leaf as a JSON number when it is one.
+func (n *xmlNode) number() (json.Number, bool) {
+	text := strings.TrimSpace(n.text)
+	if text == "" || !(text[0] == '-' || text[0] >= '0' && text[0] <= '9') || !json.Valid([]byte(text)) {
+		return "", false
+	}
+	return json.Number(text), true
+}
+
+// jsonFields maps the JSON names of the fields of struct type t, including those promoted from
+// embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for name, ft := range jsonFields(embedded) {
+					if _, ok := fields[name]; !ok {
+						fields[name] = ft
+					}
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = sf.Type
+	}
+	return fields
+}
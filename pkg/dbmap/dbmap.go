@@ -0,0 +1,222 @@
+// Package dbmap maps struct fields to table columns through db struct tags, so SELECT lists and
+// row scans follow the struct instead of being written out by hand.
+//
+//	type Student struct {
+//		ID        int        `json:"id" db:"id"`
+//		FirstName string     `json:"first_name" db:"first_name"`
+//		DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+//		Password  string     `json:"-"` // no db tag: never selected
+//	}
+//
+// Only fields with a db tag are mapped, in declaration order; fields of embedded structs are
+// promoted. The tags of each type are parsed once and cached.
+//
+// Any column may be NULL. Pointer fields become nil and types implementing sql.Scanner handle NULL
+// themselves; other fields are read through the matching sql.Null* type and keep their zero value.
+package dbmap
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scanner is satisfied by both *sql.Row and *sql.Rows.
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// Column is a struct field mapped to a table column.
+type Column struct {
+	// Name is the column name, from the db tag.
+	Name string
+	// Field is the JSON name of the field, as used by filters, sorting and PATCH documents.
+	Field string
+
+	index    []int
+	nullable bool // read through a sql.Null* type
+}
+
+// Value returns the value of the column's field in record, a struct or pointer to struct.
+func (c Column) Value(record any) any {
+	return reflect.Indirect(reflect.ValueOf(record)).FieldByIndex(c.index).Interface()
+}
+
+var columnCache sync.Map // reflect.Type -> []Column
+
+// Columns returns the mapped columns of model, a struct or pointer to struct, in declaration order.
+func Columns(model any) []Column {
+	return columnsOf(reflect.Indirect(reflect.ValueOf(model)).Type())
+}
+
+func columnsOf(t reflect.Type) []Column {
+	if cached, ok := columnCache.Load(t); ok {
+		return cached.([]Column)
+	}
+
+	var columns []Column
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("db"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			for _, c := range columnsOf(sf.Type) {
+				c.index = append([]int{i}, c.index...)
+				columns = append(columns, c)
+			}
+			continue
+		}
+		if !sf.IsExported() || name == "" {
+			continue
+		}
+
+		field, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if field == "" || field == "-" {
+			field = sf.Name
+		}
+		columns = append(columns, Column{
+			Name:     name,
+			Field:    field,
+			index:    []int{i},
+			nullable: needsNull(sf.Type),
+		})
+	}
+
+	columnCache.Store(t, columns)
+	return columns
+}
+
+var scannerType = reflect.TypeFor[sql.Scanner]()
+
+// needsNull reports whether a field of type t cannot hold NULL by itself. Pointers become nil,
+// byte slices empty, and scanners decide for themselves.
+func needsNull(t reflect.Type) bool {
+	switch {
+	case t.Kind() == reflect.Pointer, reflect.PointerTo(t).Implements(scannerType):
+		return false
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return false
+	}
+	return true
+}
+
+// Find returns the column of model called name.
+func Find(model any, name string) (Column, bool) {
+	for _, c := range Columns(model) {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Names returns the names of columns, in order.
+func Names(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// List returns the SELECT list of model's columns, e.g. "id, first_name, last_name".
+func List(model any) string {
+	return strings.Join(Names(Columns(model)), ", ")
+}
+
+// Scan reads a row selected with List(dst) into dst, a pointer to struct.
+func Scan(s Scanner, dst any) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dbmap: %T is not a pointer to struct", dst)
+	}
+	value = value.Elem()
+
+	columns := columnsOf(value.Type())
+	targets := make([]any, len(columns))
+	for i, c := range columns {
+		field := value.FieldByIndex(c.index)
+		if c.nullable {
+			targets[i] = nullable{column: c.Name, field: field}
+		} else {
+			targets[i] = field.Addr().Interface()
+		}
+	}
+	return s.Scan(targets...)
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// nullable scans a column into a field with no NULL of its own through the matching sql.Null*
+// type, leaving the field's zero value for NULL.
+type nullable struct {
+	column string
+	field  reflect.Value
+}
+
+func (n nullable) Scan(src any) error {
+	n.field.SetZero()
+	if src == nil {
+		return nil
+	}
+
+	if n.field.Type() == timeType {
+		var v sql.NullTime
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		n.field.Set(reflect.ValueOf(v.Time))
+		return nil
+	}
+
+	switch n.field.Kind() {
+	case reflect.String:
+		var v sql.NullString
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		n.field.SetString(v.String)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var v sql.NullInt64
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		if n.field.OverflowInt(v.Int64) {
+			return n.error(fmt.Errorf("value %d overflows %s", v.Int64, n.field.Type()))
+		}
+		n.field.SetInt(v.Int64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var v sql.NullInt64
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		if v.Int64 < 0 || n.field.OverflowUint(uint64(v.Int64)) {
+			return n.error(fmt.Errorf("value %d overflows %s", v.Int64, n.field.Type()))
+		}
+		n.field.SetUint(uint64(v.Int64))
+	case reflect.Float32, reflect.Float64:
+		var v sql.NullFloat64
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		n.field.SetFloat(v.Float64)
+	case reflect.Bool:
+		var v sql.NullBool
+		if err := v.Scan(src); err != nil {
+			return n.error(err)
+		}
+		n.field.SetBool(v.Bool)
+	default:
+		return n.error(fmt.Errorf("unsupported type %s", n.field.Type()))
+	}
+	return nil
+}
+
+func (n nullable) error(err error) error {
+	return fmt.Errorf("dbmap: column %s: %w", n.column, err)
+}
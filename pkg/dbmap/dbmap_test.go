@@ -0,0 +1,201 @@
+package dbmap
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID        int       `json:"id" db:"id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type row struct {
+	base
+	Name     string         `json:"name" db:"name"`
+	Age      int8           `json:"age" db:"age"`
+	Count    uint16         `db:"count"`
+	Score    float64        `json:"score,omitempty" db:"score"`
+	Active   bool           `json:"active" db:"active"`
+	Nickname *string        `json:"nickname,omitempty" db:"nickname"`
+	Notes    sql.NullString `json:"notes" db:"notes"`
+	Data     []byte         `json:"-" db:"data"`
+	Skipped  string         `json:"skipped" db:"-"`
+	Untagged string         `json:"untagged"`
+	hidden   string         `db:"hidden"`
+}
+
+// fakeRow scans its values like database/sql: scanners scan themselves and other targets are
+// assigned, NULL giving nil pointers and slices.
+type fakeRow []any
+
+func (r fakeRow) Scan(dest ...any) error {
+	if len(dest) != len(r) {
+		return errors.New("wrong number of targets")
+	}
+	for i, d := range dest {
+		if s, ok := d.(sql.Scanner); ok {
+			if err := s.Scan(r[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		target := reflect.ValueOf(d).Elem()
+		if r[i] == nil {
+			target.SetZero()
+			continue
+		}
+		src := reflect.ValueOf(r[i])
+		if target.Kind() == reflect.Pointer {
+			p := reflect.New(target.Type().Elem())
+			p.Elem().Set(src.Convert(p.Elem().Type()))
+			target.Set(p)
+			continue
+		}
+		target.Set(src.Convert(target.Type()))
+	}
+	return nil
+}
+
+func TestColumns(t *testing.T) {
+	if got, want := List(row{}), "id, created_at, name, age, count, score, active, nickname, notes, data"; got != want {
+		t.Errorf("List() = %q, want %q", got, want)
+	}
+
+	var fields []string
+	for _, c := range Columns(&row{}) {
+		fields = append(fields, c.Field)
+	}
+	want := []string{"id", "created_at", "name", "age", "Count", "score", "active", "nickname", "notes", "Data"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Columns() fields = %v, want %v", fields, want)
+	}
+
+	record := row{base: base{ID: 3}, Name: "Ada"}
+	for name, value := range map[string]any{"id": 3, "name": "Ada", "nickname": (*string)(nil)} {
+		c, ok := Find(record, name)
+		if !ok {
+			t.Fatalf("Find(%q) found nothing", name)
+		}
+		if got := c.Value(&record); got != value {
+			t.Errorf("%s.Value() = %#v, want %#v", name, got, value)
+		}
+	}
+	for _, name := range []string{"hidden", "Skipped", "untagged", "skipped"} {
+		if _, ok := Find(row{}, name); ok {
+			t.Errorf("Find(%q) found an unmapped field", name)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	nickname := "Ace"
+	filled := row{
+		base:     base{ID: 9, CreatedAt: created},
+		Name:     "old",
+		Age:      9,
+		Count:    9,
+		Score:    9,
+		Active:   true,
+		Nickname: &nickname,
+		Notes:    sql.NullString{String: "old", Valid: true},
+		Data:     []byte("old"),
+		Skipped:  "kept",
+	}
+	tests := []struct {
+		name    string
+		values  fakeRow
+		want    row
+		wantErr bool
+	}{
+		{
+			name:   "values",
+			values: fakeRow{int64(1), created, []byte("Ada"), int64(36), int64(7), []byte("2.5"), int64(1), []byte("Ace"), "n", []byte{1}},
+			want: row{
+				base:     base{ID: 1, CreatedAt: created},
+				Name:     "Ada",
+				Age:      36,
+				Count:    7,
+				Score:    2.5,
+				Active:   true,
+				Nickname: &nickname,
+				Notes:    sql.NullString{String: "n", Valid: true},
+				Data:     []byte{1},
+				Skipped:  "kept",
+			},
+		},
+		{
+			name:   "NULL into every field",
+			values: fakeRow{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+			want:   row{Skipped: "kept"},
+		},
+		{
+			name:    "time as text",
+			values:  fakeRow{int64(1), "2024-01-02T03:04:05Z", "Ada", nil, nil, nil, false, nil, nil, nil},
+			wantErr: true,
+		},
+		{
+			name:    "signed overflow",
+			values:  fakeRow{int64(1), created, "Ada", int64(200), nil, nil, nil, nil, nil, nil},
+			wantErr: true,
+		},
+		{
+			name:    "negative unsigned",
+			values:  fakeRow{int64(1), created, "Ada", nil, int64(-1), nil, nil, nil, nil, nil},
+			wantErr: true,
+		},
+		{
+			name:    "unsigned overflow",
+			values:  fakeRow{int64(1), created, "Ada", nil, int64(70000), nil, nil, nil, nil, nil},
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			values:  fakeRow{[]byte("one"), created, "Ada", nil, nil, nil, nil, nil, nil, nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filled
+			err := Scan(tt.values, &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	var r row
+	if err := Scan(fakeRow{}, r); err == nil {
+		t.Error("Scan() into a struct value succeeded")
+	}
+	var n int
+	if err := Scan(fakeRow{}, &n); err == nil {
+		t.Error("Scan() into a pointer to int succeeded")
+	}
+
+	var unsupported struct {
+		Tags []string `db:"tags"`
+	}
+	if err := Scan(fakeRow{nil}, &unsupported); err != nil {
+		t.Errorf("Scan() of NULL into an unsupported type error = %v", err)
+	}
+	if err := Scan(fakeRow{"a,b"}, &unsupported); err == nil {
+		t.Error("Scan() into an unsupported type succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+// Package notifier delivers out-of-band messages (password reset links, alerts) to users.
+// Implementations are selected at startup so local development can log messages instead of sending them.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is a notification addressed to a single recipient.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers messages.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the standard logger. Intended for local development only.
+type LogNotifier struct{}
+
+func (LogNotifier) Send(_ context.Context, msg Message) error {
+	log.Printf("[notifier] to=%s subject=%q body=%q", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier appends messages as JSON lines to a file.
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileNotifier) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening notifier file: %w", err)
+	}
+	defer file.Close()
+
+	entry := struct {
+		Time time.Time `json:"time"`
+		Message
+	}{Time: time.Now().UTC(), Message: msg}
+	return json.NewEncoder(file).Encode(entry)
+}
+
+// FromEnv builds the notifier selected by NOTIFIER ("log" or "file").
+// The file notifier writes to NOTIFIER_FILE, defaulting to notifications.log.
+func FromEnv() (Notifier, error) {
+	switch kind := os.Getenv("NOTIFIER"); kind {
+	case "", "log":
+		return LogNotifier{}, nil
+	case "file":
+		path := os.Getenv("NOTIFIER_FILE")
+		if path == "" {
+			path = "notifications.log"
+		}
+		return &FileNotifier{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
@@ -0,0 +1,296 @@
+// Package openapi builds an OpenAPI 3.1 document from the routes of a server as they are registered.
+//
+// Operations are described with Go values rather than schemas: the body of a request or response
+// is given as a value of its type, and the document derives the JSON Schema from the type's json
+// and validate tags (see Document.Schema). Named struct types become components referenced by $ref.
+//
+//	doc := openapi.New("School API", "1.0.0")
+//	doc.Add("GET /students/{id}", openapi.Operation{
+//		Summary:   "Get a student",
+//		Responses: []openapi.Response{{Status: 200, Body: models.Student{}}},
+//	})
+//	data, err := json.Marshal(doc)
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents.
+const Version = "3.1.0"
+
+// Operation describes one route.
+type Operation struct {
+	// ID is the operationId; it defaults to one derived from the method and path.
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	// Parameters of the query, headers and path. Path parameters missing from the list are added
+	// from the document's PathParameters.
+	Parameters []Parameter
+	// Body is a value of the request body type, nil for operations without one.
+	Body any
+	// BodyTypes lists the media types the body may be sent as, defaulting to the document's MediaTypes.
+	BodyTypes []string
+	// Bodies maps media types to body values, for bodies whose schema depends on the media type.
+	// It takes precedence over Body and BodyTypes.
+	Bodies    map[string]any
+	Responses []Response
+	// Security names the security schemes the operation accepts, any one of them being enough.
+	// Nil means the document's Security; Public operations need none.
+	Security []string
+	Public   bool
+}
+
+// Parameter describes a query, header or path parameter.
+type Parameter struct {
+	Name        string
+	In          string // "query", "header" or "path"
+	Description string
+	Required    bool
+	// Type is a value of the parameter's type; a slice makes a parameter that may be repeated.
+	Type any
+	// Enum lists the allowed values.
+	Enum []string
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Status      int
+	Description string // defaults to the status text
+	// Body is a value of the response body type, nil for responses without one.
+	Body any
+	// Types lists the media types of the body, defaulting to the document's MediaTypes.
+	Types []string
+	// Headers maps response headers to their description.
+	Headers map[string]string
+}
+
+// Document is an OpenAPI document under construction. It marshals to JSON.
+type Document struct {
+	Title       string
+	Version     string
+	Description string
+	// MediaTypes are the default media types of request and response bodies.
+	MediaTypes []string
+	// SecuritySchemes maps scheme names to Security Scheme Objects.
+	SecuritySchemes map[string]any
+	// Security names the schemes accepted by operations that do not say otherwise.
+	Security []string
+	// PathParameters describes the path parameters operations leave out, by name. Others are strings.
+	PathParameters map[string]Parameter
+	// Error, when set, is the body of the default (error) response of every operation, sent as ErrorType.
+	Error     any
+	ErrorType string
+
+	paths   map[string]map[string]Operation
+	schemas map[string]*Schema
+	types   map[reflect.Type]string
+}
+
+// New returns an empty document.
+func New(title, version string) *Document {
+	return &Document{
+		Title:      title,
+		Version:    version,
+		MediaTypes: []string{"application/json"},
+		paths:      make(map[string]map[string]Operation),
+		schemas:    make(map[string]*Schema),
+		types:      make(map[reflect.Type]string),
+	}
+}
+
+// Add documents the route of an http.ServeMux pattern, "METHOD /path/{param}". Patterns without
+// a method are skipped: they match every method and say nothing about the API.
+func (d *Document) Add(pattern string, op Operation) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return
+	}
+	path = strings.TrimSpace(path)
+	if d.paths[path] == nil {
+		d.paths[path] = make(map[string]Operation)
+	}
+	d.paths[path][strings.ToLower(method)] = op
+}
+
+// operationID derives an operationId, e.g. "getStudentsId" for GET /students/{id}.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	for segment := range strings.FieldsFuncSeq(path, func(r rune) bool { return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == '_' }) {
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+// pathParameters returns the names of the {parameters} of path.
+func pathParameters(path string) []string {
+	var names []string
+	for {
+		_, rest, ok := strings.Cut(path, "{")
+		if !ok {
+			return names
+		}
+		name, after, _ := strings.Cut(rest, "}")
+		names = append(names, strings.TrimSuffix(name, "..."))
+		path = after
+	}
+}
+
+// MarshalJSON renders the document.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	paths := make(map[string]map[string]any, len(d.paths))
+	for _, path := range slices.Sorted(maps.Keys(d.paths)) {
+		item := make(map[string]any)
+		for method, op := range d.paths[path] {
+			rendered, err := d.render(method, path, op)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			item[method] = rendered
+		}
+		paths[path] = item
+	}
+
+	doc := map[string]any{
+		"openapi": Version,
+		"info":    map[string]any{"title": d.Title, "version": d.Version, "description": d.Description},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":         d.schemas,
+			"securitySchemes": d.SecuritySchemes,
+		},
+	}
+	if len(d.Security) > 0 {
+		doc["security"] = requirements(d.Security)
+	}
+	return json.Marshal(doc)
+}
+
+// requirements turns scheme names into alternative Security Requirement Objects.
+func requirements(schemes []string) []map[string][]string {
+	list := make([]map[string][]string, len(schemes))
+	for i, scheme := range schemes {
+		list[i] = map[string][]string{scheme: {}}
+	}
+	return list
+}
+
+func (d *Document) render(method, path string, op Operation) (map[string]any, error) {
+	id := op.ID
+	if id == "" {
+		id = operationID(method, path)
+	}
+	out := map[string]any{"operationId": id}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if len(op.Tags) > 0 {
+		out["tags"] = op.Tags
+	}
+	switch {
+	case op.Public:
+		out["security"] = []any{}
+	case op.Security != nil:
+		out["security"] = requirements(op.Security)
+	}
+
+	parameters := slices.Clone(op.Parameters)
+	for _, name := range pathParameters(path) {
+		if slices.ContainsFunc(parameters, func(p Parameter) bool { return p.In == "path" && p.Name == name }) {
+			continue
+		}
+		p, ok := d.PathParameters[name]
+		if !ok {
+			p.Type = ""
+		}
+		p.Name, p.In = name, "path"
+		parameters = append(parameters, p)
+	}
+	if len(parameters) > 0 {
+		list := make([]map[string]any, len(parameters))
+		for i, p := range parameters {
+			param := map[string]any{"name": p.Name, "in": p.In, "required": p.Required || p.In == "path"}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			schema := d.Schema(p.Type)
+			values := schema
+			if schema.Items != nil {
+				values = schema.Items // a repeated parameter
+			}
+			for _, v := range p.Enum {
+				values.Enum = append(values.Enum, v)
+			}
+			param["schema"] = schema
+			list[i] = param
+		}
+		out["parameters"] = list
+	}
+
+	switch {
+	case op.Bodies != nil:
+		content := make(map[string]any, len(op.Bodies))
+		for mediaType, body := range op.Bodies {
+			content[mediaType] = map[string]any{"schema": d.Schema(body)}
+		}
+		out["requestBody"] = map[string]any{"required": true, "content": content}
+	case op.Body != nil:
+		out["requestBody"] = map[string]any{"required": true, "content": d.content(op.Body, op.BodyTypes)}
+	}
+
+	responses := make(map[string]any)
+	for _, r := range op.Responses {
+		description := r.Description
+		if description == "" {
+			description = http.StatusText(r.Status)
+		}
+		response := map[string]any{"description": description}
+		if r.Body != nil {
+			response["content"] = d.content(r.Body, r.Types)
+		}
+		if len(r.Headers) > 0 {
+			headers := make(map[string]any, len(r.Headers))
+			for name, description := range r.Headers {
+				headers[name] = map[string]any{"description": description, "schema": &Schema{Type: "string"}}
+			}
+			response["headers"] = headers
+		}
+		responses[fmt.Sprint(r.Status)] = response
+	}
+	if d.Error != nil {
+		responses["default"] = map[string]any{
+			"description": "Error",
+			"content":     d.content(d.Error, []string{d.ErrorType}),
+		}
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no responses")
+	}
+	out["responses"] = responses
+	return out, nil
+}
+
+// content returns the Content map of a body, in the given media types or the document's.
+func (d *Document) content(body any, types []string) map[string]any {
+	if len(types) == 0 {
+		types = d.MediaTypes
+	}
+	schema := d.Schema(body)
+	content := make(map[string]any, len(types))
+	for _, mediaType := range types {
+		content[mediaType] = map[string]any{"schema": schema}
+	}
+	return content
+}
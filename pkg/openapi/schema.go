@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema, as far as the document uses it.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // a type name, or a list of them
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// AnyOf is a body that may take the shape of any of its values.
+type AnyOf []any
+
+// Partial is the body of a partial update of T: the fields of T, none of them required.
+type Partial[T any] struct{}
+
+func (Partial[T]) partialOf() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+type partial interface {
+	partialOf() reflect.Type
+}
+
+var (
+	partialType = reflect.TypeFor[partial]()
+	timeType    = reflect.TypeFor[time.Time]()
+	rawType     = reflect.TypeFor[json.RawMessage]()
+)
+
+// Schema returns the schema of the type of v, following encoding/json: fields are named by their
+// json tags, embedded structs are promoted and pointers may be null. Named structs are added to the
+// components and referenced; anonymous ones, such as response envelopes, are inlined. The validate
+// tags of pkg/validator become constraints: required, readonly, min, max, email and oneof.
+//
+// A nil v, or a value of an interface type, is any JSON value. See AnyOf for alternative
+// bodies and Partial for PATCH bodies.
+func (d *Document) Schema(v any) *Schema {
+	if alternatives, ok := v.(AnyOf); ok {
+		s := &Schema{}
+		for _, alternative := range alternatives {
+			s.AnyOf = append(s.AnyOf, d.Schema(alternative))
+		}
+		return s
+	}
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return &Schema{}
+	}
+	return d.schemaOf(t)
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	if t.Implements(partialType) {
+		s := d.object(reflect.Zero(t).Interface().(partial).partialOf())
+		s.Required = nil
+		return s
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(d.schemaOf(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return d.object(t)
+		}
+		return d.component(t)
+	}
+	return &Schema{}
+}
+
+// nullable allows null besides the values of s.
+func nullable(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+		return s
+	case nil:
+		if s.Ref == "" {
+			return s // any value, null included
+		}
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// component adds the named struct type t to the components, once, and returns a reference to it.
+func (d *Document) component(t reflect.Type) *Schema {
+	name, ok := d.types[t]
+	if !ok {
+		name = exported(t.Name())
+		if _, taken := d.schemas[name]; taken {
+			// Another package has a type of the same name
+			name = exported(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+		}
+		d.types[t] = name
+		d.schemas[name] = &Schema{} // placeholder for recursive types
+		d.schemas[name] = d.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// exported capitalizes name, so that unexported request types read like the others.
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// object returns the schema of the fields of the struct type t.
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.fields(t, s)
+	return s
+}
+
+func (d *Document) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, options, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		ft := sf.Type
+		if sf.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.fields(ft, s)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := d.schemaOf(ft)
+		if strings.Contains(options, "string") && field.Ref == "" {
+			field = &Schema{Type: "string"}
+		}
+		if constrain(field, sf.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = field
+	}
+}
+
+// constrain applies the rules of a validate tag to s, reporting whether the field is required.
+func constrain(s *Schema, tag string) (required bool) {
+	for entry := range strings.SplitSeq(tag, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		switch rule {
+		case "required":
+			required = true
+		case "readonly":
+			s.ReadOnly = true
+		case "email":
+			s.Format = "email"
+		case "min", "max":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case hasType(s, "string"):
+				n := int(limit)
+				if rule == "min" {
+					s.MinLength = &n
+				} else {
+					s.MaxLength = &n
+				}
+			case hasType(s, "integer"), hasType(s, "number"):
+				if rule == "min" {
+					s.Minimum = &limit
+				} else {
+					s.Maximum = &limit
+				}
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.ParseFloat(value, 64); err == nil && !hasType(s, "string") {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, value)
+				}
+			}
+		case "exists":
+			s.Description = "The id of an existing record of " + param + "."
+		}
+	}
+	return required
+}
+
+// hasType reports whether s allows values of the JSON type name.
+func hasType(s *Schema, name string) bool {
+	switch typ := s.Type.(type) {
+	case string:
+		return typ == name
+	case []string:
+		for _, t := range typ {
+			if t == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents to decoded JSON values.
+// Documents are the generic values produced by encoding/json: map[string]any, []any, string, float64, bool and nil.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types selecting the patch format of a PATCH request.
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	JSONPatchMediaType  = "application/json-patch+json"
+)
+
+// ErrTestFailed is returned by Apply when a "test" operation does not match the document.
+var ErrTestFailed = errors.New("test operation failed")
+
+// Operation is a single RFC 6902 operation. Decoding one fails when an add, replace or test
+// operation has no value member, so a missing value is never taken for null.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// UnmarshalJSON decodes an operation, telling a missing value member from a null one.
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	var raw struct {
+		operation
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*op = Operation(raw.operation)
+	if raw.Value == nil {
+		switch op.Op {
+		case "add", "replace", "test":
+			return fmt.Errorf("%s operation on %q has no value member", op.Op, op.Path)
+		}
+		return nil
+	}
+	return json.Unmarshal(raw.Value, &op.Value)
+}
+
+// Merge applies an RFC 7396 merge patch to target and returns the result. Object members set to
+// null in the patch are removed; any non-object patch replaces the target entirely.
+func Merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	} else {
+		targetObject = copyObject(targetObject)
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = Merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Apply applies RFC 6902 operations to doc in order and returns the result. The operations are atomic:
+// on any error, including ErrTestFailed, doc is left untouched and the partial result discarded.
+func Apply(doc any, ops []Operation) (any, error) {
+	doc = deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "add":
+			doc, err = add(doc, op.Path, deepCopy(op.Value))
+		case "remove":
+			doc, _, err = remove(doc, op.Path)
+		case "replace":
+			if doc, _, err = remove(doc, op.Path); err == nil {
+				doc, err = add(doc, op.Path, deepCopy(op.Value))
+			}
+		case "move":
+			var value any
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				err = errors.New("cannot move a value into one of its children")
+			} else if doc, value, err = remove(doc, op.From); err == nil {
+				doc, err = add(doc, op.Path, value)
+			}
+		case "copy":
+			var value any
+			if value, err = get(doc, op.From); err == nil {
+				doc, err = add(doc, op.Path, deepCopy(value))
+			}
+		case "test":
+			var value any
+			if value, err = get(doc, op.Path); err == nil && !equal(value, op.Value) {
+				return nil, fmt.Errorf("operation %d: %w: %s", i, ErrTestFailed, op.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown op %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", pointer)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", pointer)
+		}
+	}
+	return doc, nil
+}
+
+// add sets the value at pointer, inserting into arrays, and returns the possibly replaced root.
+func add(doc any, pointer string, value any) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+	case []any:
+		i := len(node)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return setChild(doc, tokens[:len(tokens)-1], node)
+	default:
+		return nil, fmt.Errorf("parent of %q is not an object or array", pointer)
+	}
+	return doc, nil
+}
+
+// remove deletes the value at pointer and returns the new root and the removed value.
+func remove(doc any, pointer string) (any, any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q does not exist", pointer)
+		}
+		delete(node, last)
+		return doc, value, nil
+	case []any:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = setChild(doc, tokens[:len(tokens)-1], node)
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("path %q does not exist", pointer)
+	}
+}
+
+// setChild replaces the array at tokens, needed because growing or shrinking a slice may reallocate it.
+func setChild(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+	case []any:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	}
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
+
+func joinPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func copyObject(object map[string]any) map[string]any {
+	copied := make(map[string]any, len(object))
+	for name, value := range object {
+		copied[name] = value
+	}
+	return copied
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for name, child := range v {
+			copied[name] = deepCopy(child)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,215 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+	return v
+}
+
+// TestApply runs the examples of RFC 6902 Appendix A. A.13, an operation with two op members, is
+// left out: encoding/json keeps the last duplicate member instead of rejecting the document.
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		ops     string
+		want    string // empty when the patch fails
+		testErr bool   // the failure is ErrTestFailed
+	}{
+		{
+			name: "A.1 adding an object member",
+			doc:  `{"foo": "bar"}`,
+			ops:  `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want: `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name: "A.2 adding an array element",
+			doc:  `{"foo": ["bar", "baz"]}`,
+			ops:  `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want: `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name: "A.3 removing an object member",
+			doc:  `{"baz": "qux", "foo": "bar"}`,
+			ops:  `[{"op": "remove", "path": "/baz"}]`,
+			want: `{"foo": "bar"}`,
+		},
+		{
+			name: "A.4 removing an array element",
+			doc:  `{"foo": ["bar", "qux", "baz"]}`,
+			ops:  `[{"op": "remove", "path": "/foo/1"}]`,
+			want: `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name: "A.5 replacing a value",
+			doc:  `{"baz": "qux", "foo": "bar"}`,
+			ops:  `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want: `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name: "A.6 moving a value",
+			doc:  `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			ops:  `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want: `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name: "A.7 moving an array element",
+			doc:  `{"foo": ["all", "grass", "cows", "eat"]}`,
+			ops:  `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want: `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name: "A.8 testing a value: success",
+			doc:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			ops: `[
+				{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}
+			]`,
+			want: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:    "A.9 testing a value: error",
+			doc:     `{"baz": "qux"}`,
+			ops:     `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			testErr: true,
+		},
+		{
+			name: "A.10 adding a nested member object",
+			doc:  `{"foo": "bar"}`,
+			ops:  `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want: `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name: "A.11 ignoring unrecognized elements",
+			doc:  `{"foo": "bar"}`,
+			ops:  `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want: `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name: "A.12 adding to a nonexistent target",
+			doc:  `{"foo": "bar"}`,
+			ops:  `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+		},
+		{
+			name: "A.14 ~ escape ordering",
+			doc:  `{"/": 9, "~1": 10}`,
+			ops:  `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want: `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:    "A.15 comparing strings and numbers",
+			doc:     `{"/": 9, "~1": 10}`,
+			ops:     `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			testErr: true,
+		},
+		{
+			name: "A.16 adding an array value",
+			doc:  `{"foo": ["bar"]}`,
+			ops:  `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want: `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []Operation
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("decoding operations: %v", err)
+			}
+			doc := decode(t, tt.doc)
+			got, err := Apply(doc, ops)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Apply() = %v, want an error", got)
+				}
+				if errors.Is(err, ErrTestFailed) != tt.testErr {
+					t.Errorf("Apply() error = %v, ErrTestFailed %v", err, tt.testErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply() = %v, want %v", got, want)
+			}
+			if original := decode(t, tt.doc); !reflect.DeepEqual(doc, original) {
+				t.Errorf("Apply() changed its input to %v", doc)
+			}
+		})
+	}
+}
+
+func TestOperationValue(t *testing.T) {
+	tests := []struct {
+		op      string
+		wantErr bool
+	}{
+		{`{"op": "add", "path": "/a"}`, true},
+		{`{"op": "replace", "path": "/a"}`, true},
+		{`{"op": "test", "path": "/a"}`, true},
+		{`{"op": "add", "path": "/a", "value": null}`, false},
+		{`{"op": "test", "path": "/a", "value": null}`, false},
+		{`{"op": "remove", "path": "/a"}`, false},
+		{`{"op": "move", "from": "/a", "path": "/b"}`, false},
+		{`{"op": "copy", "from": "/a", "path": "/b"}`, false},
+	}
+	for _, tt := range tests {
+		var op Operation
+		err := json.Unmarshal([]byte(tt.op), &op)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("decoding %s: error = %v, want error %v", tt.op, err, tt.wantErr)
+		}
+	}
+
+	var ops []Operation
+	if err := json.Unmarshal([]byte(`[{"op": "add", "path": "/a", "value": null}]`), &ops); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Apply(map[string]any{}, ops)
+	if want := map[string]any{"a": nil}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, %v, want %v", got, err, want)
+	}
+}
+
+// TestMerge runs the examples of RFC 7396 Appendix A.
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		target := decode(t, tt.target)
+		got := Merge(target, decode(t, tt.patch))
+		if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("Merge(%s, %s) = %v, want %v", tt.target, tt.patch, got, want)
+		}
+		if original := decode(t, tt.target); !reflect.DeepEqual(target, original) {
+			t.Errorf("Merge(%s, %s) changed its target to %v", tt.target, tt.patch, target)
+		}
+	}
+}
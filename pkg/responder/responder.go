@@ -0,0 +1,121 @@
+// Package responder writes error responses as RFC 7807 problem details (application/problem+json):
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "Student not found",
+//	  "instance": "/students/42",
+//	  "request_id": "5f0c…",
+//	  "errors": [{"field": "email", "rule": "email", "message": "must be a valid e-mail address"}]
+//	}
+//
+// Problems without a more specific type use "about:blank", whose title is the HTTP status text.
+// request_id echoes the X-Request-ID of the request so a client report can be matched with the logs,
+// and errors is only present on validation failures.
+package responder
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jorge-sader/go-rest-api/pkg/utils"
+	"github.com/jorge-sader/go-rest-api/pkg/validator"
+)
+
+// MediaType is the Content-Type of problem responses.
+const MediaType = "application/problem+json"
+
+// Problem types more specific than about:blank, as URI references relative to the API.
+const (
+	TypeValidation = "/problems/validation-failed"
+	TypeNotFound   = "/problems/no-record-found"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Status    int              `json:"status"`
+	Detail    string           `json:"detail,omitempty"`
+	Instance  string           `json:"instance,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+	Errors    validator.Errors `json:"errors,omitempty"`
+}
+
+// NewProblem returns an about:blank problem with the given status and detail.
+func NewProblem(status int, detail string) Problem {
+	return Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// Write sends p, filling in the type, title, instance and request ID when they are empty.
+// Like http.Error it leaves other headers already set on w (e.g. Retry-After or WWW-Authenticate) in place.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if r != nil {
+		if p.Instance == "" {
+			p.Instance = r.URL.Path
+		}
+		if p.RequestID == "" {
+			p.RequestID = utils.RequestIDFromContext(r.Context())
+		}
+	}
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", MediaType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Error encoding problem response: %v", err)
+	}
+}
+
+// Error answers r with a problem of the given status whose detail is message. It is the problem+json
+// counterpart of http.Error and takes its arguments in the same order.
+func Error(w http.ResponseWriter, r *http.Request, message string, status int) {
+	Write(w, r, Problem{Status: status, Detail: message})
+}
+
+// ValidationProblem returns the 422 problem listing every field that failed validation.
+func ValidationProblem(errs validator.Errors) Problem {
+	return Problem{
+		Type:   TypeValidation,
+		Title:  "Validation failed",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "One or more fields are invalid",
+		Errors: errs,
+	}
+}
+
+// ValidationFailed answers 422 Unprocessable Entity listing every field that failed validation.
+func ValidationFailed(w http.ResponseWriter, r *http.Request, errs validator.Errors) {
+	Write(w, r, ValidationProblem(errs))
+}
+
+// RespondNoRecordFound answers 404 Not Found for a lookup that matched no record.
+func RespondNoRecordFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, Problem{
+		Type:   TypeNotFound,
+		Title:  "No record found",
+		Status: http.StatusNotFound,
+	})
+}
+
+// NotAcceptable answers 406 Not Acceptable listing the media types the response is available in.
+func NotAcceptable(w http.ResponseWriter, r *http.Request, available ...string) {
+	Error(w, r, "Not acceptable: the response is available as "+strings.Join(available, ", "), http.StatusNotAcceptable)
+}
+
+// UnsupportedMediaType answers 415 Unsupported Media Type listing the media types the body may be sent as.
+func UnsupportedMediaType(w http.ResponseWriter, r *http.Request, supported ...string) {
+	w.Header().Set("Accept", strings.Join(supported, ", "))
+	Error(w, r, "Unsupported Content-Type: use "+strings.Join(supported, ", "), http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import "context"
+
+// ContextKey is the type used for values this API stores in a request context.
+type ContextKey string
+
+const principalKey ContextKey = "principal"
+
+// Kinds of authenticated principals.
+const (
+	PrincipalExecutive   = "executive"
+	PrincipalAPIKey      = "api_key"
+	PrincipalClientCert  = "client_cert"
+	PrincipalOAuthClient = "oauth_client"
+)
+
+// Principal is the authenticated caller of a request.
+// Executives and client certificates are authorized by Role through the RBAC policy;
+// API keys and OAuth2 clients by their Scopes.
+type Principal struct {
+	Kind     string
+	ID       int
+	Username string
+	Role     string
+	Scopes   []string
+
+	// Session is the refresh token family an executive's access token was issued for.
+	Session string
+
+	// MustChangePassword blocks every authorized route until the password has been changed.
+	MustChangePassword bool
+
+	// MFAEnrollRequired blocks every authorized route until two-factor authentication is enrolled,
+	// for roles the RBAC policy lists in mfa_required_roles.
+	MFAEnrollRequired bool
+}
+
+// ScopeBased reports whether the principal is authorized by scopes rather than by role.
+func (p Principal) ScopeBased() bool {
+	return p.Kind == PrincipalAPIKey || p.Kind == PrincipalOAuthClient
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+const requestIDKey ContextKey = "request_id"
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID, or "" when none was assigned.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the JWT claims issued to authenticated executives.
+type Claims struct {
+	UserID             int    `json:"uid"`
+	Username           string `json:"user"`
+	Role               string `json:"role"`
+	MustChangePassword bool   `json:"mcp,omitempty"`
+	MFAEnrollRequired  bool   `json:"mfa_enroll,omitempty"`
+	SessionID          string `json:"sid,omitempty"`
+
+	// Purpose is empty for access tokens. Restricted tokens, such as the partial token
+	// issued between the password and TOTP login steps, set it and are rejected as access tokens.
+	Purpose string `json:"pur,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// PurposeMFA marks the partial token exchanged for a full token at POST /auth/2fa/verify.
+const PurposeMFA = "mfa"
+
+// Principal returns the authenticated caller described by the claims.
+func (c *Claims) Principal() Principal {
+	return Principal{
+		Kind:               PrincipalExecutive,
+		ID:                 c.UserID,
+		Username:           c.Username,
+		Role:               c.Role,
+		MustChangePassword: c.MustChangePassword,
+		MFAEnrollRequired:  c.MFAEnrollRequired,
+		Session:            c.SessionID,
+	}
+}
+
+// SignToken issues a signed HS256 access token for the given principal.
+// The secret is read from JWT_SECRET and the lifetime from JWT_EXPIRES_IN (e.g. "15m").
+func SignToken(p Principal) (string, error) {
+	expiresIn, err := time.ParseDuration(os.Getenv("JWT_EXPIRES_IN"))
+	if err != nil || expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	return signToken(p, "", expiresIn)
+}
+
+// SignMFAToken issues the short-lived partial token returned after a correct password
+// when the executive still has to present a TOTP or recovery code.
+func SignMFAToken(p Principal) (string, error) {
+	return signToken(p, PurposeMFA, 5*time.Minute)
+}
+
+func signToken(p Principal, purpose string, expiresIn time.Duration) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errors.New("JWT_SECRET is not set")
+	}
+
+	now := time.Now()
+	claims := Claims{
+		UserID:             p.ID,
+		Username:           p.Username,
+		Role:               p.Role,
+		MustChangePassword: p.MustChangePassword,
+		MFAEnrollRequired:  p.MFAEnrollRequired,
+		SessionID:          p.Session,
+		Purpose:            purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(p.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseToken validates a signed access token and returns its claims.
+func ParseToken(tokenString string) (*Claims, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testPrincipal = Principal{
+	Kind:               PrincipalExecutive,
+	ID:                 7,
+	Username:           "jdoe",
+	Role:               "registrar",
+	MustChangePassword: true,
+	Session:            "family-1",
+}
+
+func TestSignToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_EXPIRES_IN", "1m")
+
+	token, err := SignToken(testPrincipal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.Purpose != "" {
+		t.Errorf("access token purpose = %q, want none", claims.Purpose)
+	}
+	if got := claims.Principal(); got.Kind != testPrincipal.Kind || got.ID != testPrincipal.ID ||
+		got.Username != testPrincipal.Username || got.Role != testPrincipal.Role ||
+		got.MustChangePassword != testPrincipal.MustChangePassword || got.Session != testPrincipal.Session {
+		t.Errorf("Principal() = %+v, want %+v", got, testPrincipal)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != time.Minute {
+		t.Errorf("lifetime = %v, want JWT_EXPIRES_IN", lifetime)
+	}
+	if IsOAuthToken(token) {
+		t.Error("IsOAuthToken(access token) = true")
+	}
+}
+
+// TestSignMFAToken checks that the partial login token carries its purpose, which the
+// authentication middleware rejects as an access token.
+func TestSignMFAToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token, err := SignMFAToken(testPrincipal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.Purpose != PurposeMFA {
+		t.Errorf("MFA token purpose = %q, want %q", claims.Purpose, PurposeMFA)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != 5*time.Minute {
+		t.Errorf("lifetime = %v, want 5m", lifetime)
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, err := SignToken(testPrincipal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, key any, claims Claims) string {
+		t.Helper()
+		signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	// The payload of another principal's token under this token's signature
+	other, err := SignToken(Principal{Kind: PrincipalExecutive, ID: 1, Role: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, otherParts := strings.Split(token, "."), strings.Split(other, ".")
+	tampered := parts[0] + "." + otherParts[1] + "." + parts[2]
+
+	now := time.Now()
+	valid := jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))}
+	expired := jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now.Add(-time.Hour)), ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}
+
+	tests := map[string]string{
+		"tampered":             tampered,
+		"other secret":         sign(jwt.SigningMethodHS256, []byte("other-secret"), Claims{UserID: 7, RegisteredClaims: valid}),
+		"expired":              sign(jwt.SigningMethodHS256, []byte("test-secret"), Claims{UserID: 7, RegisteredClaims: expired}),
+		"other HMAC algorithm": sign(jwt.SigningMethodHS512, []byte("test-secret"), Claims{UserID: 7, RegisteredClaims: valid}),
+		"unsigned":             sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, Claims{UserID: 7, RegisteredClaims: valid}),
+		"not a token":          "not-a-token",
+	}
+	for name, token := range tests {
+		if claims, err := ParseToken(token); err == nil {
+			t.Errorf("%s: ParseToken() = %+v, want an error", name, claims)
+		}
+	}
+}
+
+func TestTokensNeedSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, err := SignToken(testPrincipal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	if _, err := SignToken(testPrincipal); err == nil {
+		t.Error("SignToken() without JWT_SECRET succeeded")
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Error("ParseToken() without JWT_SECRET succeeded")
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params holds the tunable cost parameters used when hashing passwords.
+type Argon2Params struct {
+	Memory      uint32 // memory in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follows the OWASP recommendation for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// Argon2ParamsFromEnv returns DefaultArgon2Params overridden by ARGON2_MEMORY_KIB,
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM when they are set.
+func Argon2ParamsFromEnv() Argon2Params {
+	params := DefaultArgon2Params
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY_KIB"), 10, 32); err == nil && v > 0 {
+		params.Memory = uint32(v)
+	}
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); err == nil && v > 0 {
+		params.Iterations = uint32(v)
+	}
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); err == nil && v > 0 {
+		params.Parallelism = uint8(v)
+	}
+	return params
+}
+
+// HashPassword hashes a password with argon2id and a random per-password salt.
+// The result is encoded as $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>.
+func HashPassword(password string) (string, error) {
+	params := Argon2ParamsFromEnv()
+
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generating salt: %w", err)
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// IsPasswordHashed reports whether stored is an argon2id hash rather than a legacy plaintext value.
+func IsPasswordHashed(stored string) bool {
+	return strings.HasPrefix(stored, argon2idPrefix)
+}
+
+// VerifyPassword compares password against a stored argon2id hash in constant time.
+// Legacy plaintext values are also compared in constant time so they can be migrated on login.
+func VerifyPassword(password, stored string) (bool, error) {
+	if !IsPasswordHashed(stored) {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1, nil
+	}
+
+	params, salt, hash, err := decodePasswordHash(stored)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(hash, candidate) == 1, nil
+}
+
+// PasswordNeedsRehash reports whether stored is plaintext or was hashed with parameters
+// other than the currently configured ones.
+func PasswordNeedsRehash(stored string) bool {
+	if !IsPasswordHashed(stored) {
+		return true
+	}
+	params, salt, hash, err := decodePasswordHash(stored)
+	if err != nil {
+		return true
+	}
+	current := Argon2ParamsFromEnv()
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		uint32(len(salt)) != current.SaltLength ||
+		uint32(len(hash)) != current.KeyLength
+}
+
+// decodePasswordHash parses an encoded argon2id hash into its parameters, salt and key.
+func decodePasswordHash(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(hash))
+	return params, salt, hash, nil
+}
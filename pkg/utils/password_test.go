@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheapArgon2 lowers the argon2id cost so hashing in tests is fast.
+func cheapArgon2(t *testing.T) {
+	t.Helper()
+	t.Setenv("ARGON2_MEMORY_KIB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+}
+
+func TestHashPassword(t *testing.T) {
+	cheapArgon2(t)
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") || !IsPasswordHashed(hash) {
+		t.Fatalf("HashPassword() = %q, want an argon2id hash with the configured parameters", hash)
+	}
+
+	if ok, err := VerifyPassword("correct horse battery staple", hash); !ok || err != nil {
+		t.Errorf("VerifyPassword(right password) = %v, %v, want true", ok, err)
+	}
+	for _, wrong := range []string{"", "correct horse battery stapler", "Correct horse battery staple"} {
+		if ok, err := VerifyPassword(wrong, hash); ok || err != nil {
+			t.Errorf("VerifyPassword(%q) = %v, %v, want false", wrong, ok, err)
+		}
+	}
+
+	// Salts are random, so the same password never hashes the same way twice
+	if again, _ := HashPassword("correct horse battery staple"); again == hash {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestVerifyPasswordLegacy(t *testing.T) {
+	if ok, err := VerifyPassword("secret", "secret"); !ok || err != nil {
+		t.Errorf("VerifyPassword(plaintext match) = %v, %v, want true", ok, err)
+	}
+	if ok, err := VerifyPassword("Secret", "secret"); ok || err != nil {
+		t.Errorf("VerifyPassword(plaintext mismatch) = %v, %v, want false", ok, err)
+	}
+}
+
+func TestVerifyPasswordInvalidHash(t *testing.T) {
+	for _, stored := range []string{
+		"$argon2id$",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$aGFzaA",
+	} {
+		if ok, err := VerifyPassword("password", stored); ok || !errors.Is(err, ErrInvalidPasswordHash) {
+			t.Errorf("VerifyPassword(%q) = %v, %v, want ErrInvalidPasswordHash", stored, ok, err)
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	cheapArgon2(t)
+	hash, err := HashPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("hash with the current parameters needs a rehash")
+	}
+	if !PasswordNeedsRehash("password") {
+		t.Error("plaintext password does not need a rehash")
+	}
+	if !PasswordNeedsRehash("$argon2id$broken") {
+		t.Error("invalid hash does not need a rehash")
+	}
+
+	for name, value := range map[string]string{"ARGON2_MEMORY_KIB": "2048", "ARGON2_ITERATIONS": "2", "ARGON2_PARALLELISM": "2"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if !PasswordNeedsRehash(hash) {
+				t.Errorf("hash does not need a rehash after %s changed", name)
+			}
+			// A hash still verifies with the parameters it records, whatever the current ones
+			if ok, err := VerifyPassword("password", hash); !ok || err != nil {
+				t.Errorf("VerifyPassword() = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
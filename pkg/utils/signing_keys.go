@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Asymmetric (RS256) keys sign OAuth2 access tokens so other services can verify them offline
+// using the JWKS document. Executive session tokens keep using the HS256 JWT_SECRET.
+
+type signingKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+var (
+	signingKeysMu sync.RWMutex
+	signingKeys   []signingKey // signingKeys[0] signs; the rest are only published for verification
+)
+
+// LoadSigningKeys loads RSA private keys from the comma separated PEM files in OAUTH_SIGNING_KEY_FILES.
+// The first key signs new tokens; later keys remain published so tokens signed before a rotation
+// keep verifying. Without the variable an ephemeral key is generated, which is only suitable for development.
+func LoadSigningKeys() error {
+	var keys []signingKey
+
+	files := os.Getenv("OAUTH_SIGNING_KEY_FILES")
+	if files == "" {
+		log.Println("OAUTH_SIGNING_KEY_FILES not set: generating an ephemeral OAuth signing key")
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, signingKey{kid: keyID(&key.PublicKey), key: key})
+	}
+
+	for path := range strings.SplitSeq(files, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		key, err := readRSAPrivateKey(path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, signingKey{kid: keyID(&key.PublicKey), key: key})
+	}
+
+	if len(keys) == 0 {
+		return errors.New("no OAuth signing keys configured")
+	}
+
+	signingKeysMu.Lock()
+	signingKeys = keys
+	signingKeysMu.Unlock()
+	return nil
+}
+
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// keyID is the RFC 7638 JWK thumbprint of an RSA public key.
+func keyID(pub *rsa.PublicKey) string {
+	thumbprint := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		base64.RawURLEncoding.EncodeToString(pub.N.Bytes()))
+	sum := sha256.Sum256([]byte(thumbprint))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OAuthClaims are the claims of an OAuth2 client_credentials access token.
+type OAuthClaims struct {
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Principal returns the machine principal described by the claims.
+func (c *OAuthClaims) Principal() Principal {
+	return Principal{
+		Kind:     PrincipalOAuthClient,
+		Username: "client:" + c.ClientID,
+		Scopes:   strings.Fields(c.Scope),
+	}
+}
+
+// OAuthIssuer returns OAUTH_ISSUER, the iss claim of OAuth2 access tokens.
+func OAuthIssuer() string {
+	if issuer := os.Getenv("OAUTH_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "go-rest-api"
+}
+
+// SignOAuthToken issues an RS256 access token for a client with the granted scopes.
+func SignOAuthToken(clientID string, scopes []string, expiresIn time.Duration) (string, *OAuthClaims, error) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	if len(signingKeys) == 0 {
+		return "", nil, errors.New("OAuth signing keys are not loaded")
+	}
+	active := signingKeys[0]
+
+	jti, err := RandomHex(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &OAuthClaims{
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    OAuthIssuer(),
+			Subject:   clientID,
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = active.kid
+	signed, err := token.SignedString(active.key)
+	return signed, claims, err
+}
+
+// ParseOAuthToken verifies an RS256 access token against the published keys.
+func ParseOAuthToken(tokenString string) (*OAuthClaims, error) {
+	claims := &OAuthClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		signingKeysMu.RLock()
+		defer signingKeysMu.RUnlock()
+		for _, k := range signingKeys {
+			if k.kid == kid {
+				return &k.key.PublicKey, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(OAuthIssuer()))
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// IsOAuthToken reports whether tokenString is signed with an algorithm used for OAuth2 tokens,
+// without verifying it.
+func IsOAuthToken(tokenString string) bool {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	return err == nil && token.Method.Alg() == jwt.SigningMethodRS256.Alg()
+}
+
+// JWKS returns the JSON Web Key Set (RFC 7517) of every published signing key.
+func JWKS() ([]byte, error) {
+	type jwk struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+
+	signingKeysMu.RLock()
+	keys := make([]jwk, 0, len(signingKeys))
+	for _, k := range signingKeys {
+		pub := k.key.Public().(*rsa.PublicKey)
+		keys = append(keys, jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: k.kid,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+	signingKeysMu.RUnlock()
+
+	return json.Marshal(struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: keys})
+}
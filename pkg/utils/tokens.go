@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateOpaqueToken returns a random URL-safe token of n bytes of entropy together with
+// the hash that should be persisted in its place.
+func GenerateOpaqueToken(n int) (token string, hash string, err error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generating token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a high-entropy opaque token.
+// Unlike passwords these tokens are random, so a fast hash is sufficient for storage.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RandomHex returns n random bytes encoded as hex.
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateAPIKey returns a new API key of the form "sk_<prefix>_<secret>", its non-secret
+// display prefix ("sk_<prefix>") and the hash to persist.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	id, err := RandomHex(4)
+	if err != nil {
+		return "", "", "", err
+	}
+	secret, _, err := GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	prefix = "sk_" + id
+	key = prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238 defaults understood by every authenticator app.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1 // accept one step before and after to tolerate clock drift
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random 160-bit secret encoded as unpadded base32.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI builds the otpauth:// URI that authenticator apps import (usually as a QR code).
+func TOTPURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// ValidateTOTP checks code against secret at time t and returns the matched time step.
+// Callers must reject steps less than or equal to the last accepted one so each code is single-use.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected := hotp(key, step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hotp computes the RFC 4226 HOTP value for a counter.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range totpDigits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// GenerateRecoveryCodes returns n human-friendly single-use recovery codes (xxxxx-xxxxx).
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// Random bytes at or above the largest multiple of the alphabet size are discarded, so that
+	// every character is equally likely
+	const limit = 256 - 256%len(alphabet)
+
+	codes := make([]string, n)
+	buf := make([]byte, 16)
+	for i := range codes {
+		code := make([]byte, 0, 11)
+		for len(code) < cap(code) {
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			for _, b := range buf {
+				if int(b) >= limit || len(code) == cap(code) {
+					continue
+				}
+				if len(code) == 5 {
+					code = append(code, '-')
+				}
+				code = append(code, alphabet[int(b)%len(alphabet)])
+			}
+		}
+		codes[i] = string(code)
+	}
+	return codes, nil
+}
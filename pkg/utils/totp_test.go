@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The shared secret of the RFC 4226 and RFC 6238 test vectors.
+const rfcSecret = "12345678901234567890"
+
+// TestHOTP checks the RFC 4226 Appendix D values.
+func TestHOTP(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp([]byte(rfcSecret), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// TestValidateTOTP checks the SHA1 vectors of RFC 6238 Appendix B, the only algorithm issued
+// secrets use. The RFC lists 8-digit codes; 6-digit codes are their last six digits.
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		code := tt.code[len(tt.code)-totpDigits:]
+		step, ok := ValidateTOTP(secret, code, time.Unix(tt.unix, 0))
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP(%s at %d) = %d, %v, want %d, true", code, tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	key := []byte(rfcSecret)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		if step, ok := ValidateTOTP(secret, hotp(key, current+offset), now); !ok || step != current+offset {
+			t.Errorf("code of step %+d: ValidateTOTP() = %d, %v, want %d, true", offset, step, ok, current+offset)
+		}
+	}
+	for _, offset := range []int64{-2, 2} {
+		if _, ok := ValidateTOTP(secret, hotp(key, current+offset), now); ok {
+			t.Errorf("code of step %+d was accepted", offset)
+		}
+	}
+
+	// Secrets are accepted in lower case and with surrounding spaces, as users paste them
+	if _, ok := ValidateTOTP(" "+strings.ToLower(secret)+" ", hotp(key, current), now); !ok {
+		t.Error("lower-case secret was rejected")
+	}
+	for _, code := range []string{"", "05047", "0050471", "abcdef"} {
+		if _, ok := ValidateTOTP(secret, code, now); ok {
+			t.Errorf("code %q was accepted", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", hotp(key, current), now); ok {
+		t.Error("invalid secret was accepted")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, %v, want 20", secret, len(key), err)
+	}
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, hotp(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("code of a generated secret was rejected")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes, err := GenerateRecoveryCodes(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 200 {
+		t.Fatalf("got %d codes, want 200", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	used := make(map[rune]bool)
+	for _, code := range codes {
+		first, second, ok := strings.Cut(code, "-")
+		if !ok || len(first) != 5 || len(second) != 5 || strings.Trim(first+second, alphabet) != "" {
+			t.Errorf("code %q is not xxxxx-xxxxx from the alphabet", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+		for _, c := range first + second {
+			used[c] = true
+		}
+	}
+	// 2000 characters leave a chance of about 31 × (30/31)^2000 that one is never drawn
+	if len(used) != len(alphabet) {
+		t.Errorf("codes use %d characters of the alphabet, want %d", len(used), len(alphabet))
+	}
+}
@@ -0,0 +1,320 @@
+// Package validator checks structs against rules declared in `validate` struct tags and reports
+// every failing field at once. Fields are named by their JSON name.
+//
+//	type Student struct {
+//		ID          int    `json:"id" validate:"readonly"`
+//		Email       string `json:"email" validate:"required,email,max=255"`
+//		ClassroomID int    `json:"classroom_id" validate:"required,exists=classrooms"`
+//	}
+//
+// Rules:
+//
+//	required       the value must not be empty (zero) or null
+//	email          a bare e-mail address such as "jane@example.com"
+//	min=N, max=N   length in characters for strings, value for numbers
+//	oneof=a b c    one of the space separated values
+//	exists=table   a row with this id exists in table (checked through Validator.Exists)
+//	readonly       set by the server: ignored by Struct, rejected by Fields
+//
+// Optional fields that are empty skip every other rule.
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes one field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists every field that failed validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Prefixed returns the errors with prefix prepended to every field name, e.g. "[3]." for batch items.
+func (e Errors) Prefixed(prefix string) Errors {
+	prefixed := make(Errors, len(e))
+	for i, fieldErr := range e {
+		fieldErr.Field = prefix + fieldErr.Field
+		prefixed[i] = fieldErr
+	}
+	return prefixed
+}
+
+// ExistsFunc reports whether a row with the given id exists in table.
+type ExistsFunc func(ctx context.Context, table string, id any) (bool, error)
+
+// Validator applies validate tags. Exists backs the exists rule; without it exists rules are skipped.
+type Validator struct {
+	Exists ExistsFunc
+}
+
+// rule is a parsed validate tag entry.
+type rule struct {
+	name  string
+	param string
+}
+
+type field struct {
+	index    int
+	name     string // JSON name
+	typ      reflect.Type
+	rules    []rule
+	required bool
+	readonly bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// fieldsOf returns the validated fields of a struct type, parsing its tags once.
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := field{index: i, name: name, typ: sf.Type}
+		for entry := range strings.SplitSeq(sf.Tag.Get("validate"), ",") {
+			ruleName, param, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			switch ruleName {
+			case "":
+			case "required":
+				f.required = true
+			case "readonly":
+				f.readonly = true
+			default:
+				f.rules = append(f.rules, rule{name: ruleName, param: param})
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// WritableFields returns the JSON names of the fields of model, a struct or pointer to struct, that
+// clients may set, in declaration order.
+func WritableFields(model any) []string {
+	var names []string
+	for _, f := range fieldsOf(reflect.Indirect(reflect.ValueOf(model)).Type()) {
+		if !f.readonly {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// Struct validates every field of s, a struct or pointer to struct, as a complete record for a
+// create or full replacement. The returned error is only set when a check could not be performed.
+func (v Validator) Struct(ctx context.Context, s any) (Errors, error) {
+	value := reflect.Indirect(reflect.ValueOf(s))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("validator: %T is not a struct", s)
+	}
+
+	var errs Errors
+	for _, f := range fieldsOf(value.Type()) {
+		if f.readonly {
+			continue
+		}
+		fieldErr, err := v.check(ctx, f, value.Field(f.index))
+		if err != nil {
+			return nil, err
+		}
+		if fieldErr != nil {
+			errs = append(errs, *fieldErr)
+		}
+	}
+	return errs, nil
+}
+
+// Fields validates a partial update of model given as JSON field names and decoded JSON values.
+// Unknown and readonly fields are errors, and every value must fit the field's type. Valid values
+// are converted in place to the field's Go type (e.g. float64 to int) so they can be bound directly.
+func (v Validator) Fields(ctx context.Context, model any, values map[string]any) (Errors, error) {
+	t := reflect.Indirect(reflect.ValueOf(model)).Type()
+	byName := make(map[string]field)
+	for _, f := range fieldsOf(t) {
+		byName[f.name] = f
+	}
+
+	var errs Errors
+	for name, raw := range values {
+		f, ok := byName[name]
+		switch {
+		case !ok:
+			errs = append(errs, FieldError{Field: name, Rule: "unknown", Message: "unknown field"})
+			continue
+		case f.readonly:
+			errs = append(errs, FieldError{Field: name, Rule: "readonly", Message: "field cannot be modified"})
+			continue
+		case raw == nil:
+			if f.required {
+				errs = append(errs, FieldError{Field: name, Rule: "required", Message: "field is required and cannot be null"})
+			}
+			continue
+		}
+
+		converted, err := convert(raw, f.typ)
+		if err != nil {
+			errs = append(errs, FieldError{Field: name, Rule: "type", Message: "must be " + typeName(f.typ)})
+			continue
+		}
+		fieldErr, err := v.check(ctx, f, converted)
+		if err != nil {
+			return nil, err
+		}
+		if fieldErr != nil {
+			errs = append(errs, *fieldErr)
+			continue
+		}
+		values[name] = converted.Interface()
+	}
+	slices.SortFunc(errs, func(a, b FieldError) int { return strings.Compare(a.Field, b.Field) })
+	return errs, nil
+}
+
+// check applies the rules of f to value and returns the first failure.
+func (v Validator) check(ctx context.Context, f field, value reflect.Value) (*FieldError, error) {
+	fail := func(rule, format string, args ...any) (*FieldError, error) {
+		return &FieldError{Field: f.name, Rule: rule, Message: fmt.Sprintf(format, args...)}, nil
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if f.required {
+				return fail("required", "field is required")
+			}
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if value.IsZero() {
+		if f.required {
+			return fail("required", "field is required")
+		}
+		return nil, nil
+	}
+
+	for _, r := range f.rules {
+		switch r.name {
+		case "email":
+			address, err := mail.ParseAddress(value.String())
+			if value.Kind() != reflect.String || err != nil || address.Address != value.String() {
+				return fail(r.name, "must be a valid e-mail address")
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(r.param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("validator: invalid %s=%q on %s", r.name, r.param, f.name)
+			}
+			n, unit := measure(value)
+			if r.name == "min" && n < limit {
+				return fail(r.name, "must be at least %s%s", r.param, unit)
+			}
+			if r.name == "max" && n > limit {
+				return fail(r.name, "must be at most %s%s", r.param, unit)
+			}
+		case "oneof":
+			allowed := strings.Fields(r.param)
+			if !slices.Contains(allowed, fmt.Sprint(value.Interface())) {
+				return fail(r.name, "must be one of: %s", strings.Join(allowed, ", "))
+			}
+		case "exists":
+			if v.Exists == nil {
+				continue
+			}
+			ok, err := v.Exists(ctx, r.param, value.Interface())
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return fail(r.name, "no %s record with id %v", strings.TrimSuffix(r.param, "s"), value.Interface())
+			}
+		default:
+			return nil, fmt.Errorf("validator: unknown rule %q on %s", r.name, f.name)
+		}
+	}
+	return nil, nil
+}
+
+// measure returns the size that min and max compare: characters for strings, the value for numbers.
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	case reflect.Slice, reflect.Map:
+		return float64(value.Len()), " items"
+	}
+	return 0, ""
+}
+
+// convert re-decodes a generic JSON value into t, rejecting values of the wrong type.
+func convert(raw any, t reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	target := reflect.New(t)
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return target.Elem(), nil
+}
+
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a valid " + t.String()
+}
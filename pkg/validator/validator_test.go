@@ -0,0 +1,171 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type record struct {
+	ID      int     `json:"id" validate:"readonly"`
+	Name    string  `json:"name" validate:"required,min=2,max=5"`
+	Email   string  `json:"email" validate:"email"`
+	Age     int     `json:"age" validate:"min=18,max=99"`
+	Role    string  `json:"role" validate:"oneof=admin staff"`
+	RoomID  int     `json:"room_id" validate:"exists=classrooms"`
+	Note    *string `json:"note" validate:"max=3"`
+	Secret  string  `json:"-" validate:"required"`
+	private string
+}
+
+var errLookup = errors.New("lookup failed")
+
+// exists knows classroom 1 and fails to look up classroom 500.
+func exists(ctx context.Context, table string, id any) (bool, error) {
+	if table != "classrooms" {
+		return false, errors.New("unexpected table " + table)
+	}
+	if id == 500 {
+		return false, errLookup
+	}
+	return id == 1, nil
+}
+
+// failures lists the failing fields and rules as "field:rule".
+func failures(errs Errors) []string {
+	var got []string
+	for _, fieldErr := range errs {
+		got = append(got, fieldErr.Field+":"+fieldErr.Rule)
+	}
+	return got
+}
+
+func TestStruct(t *testing.T) {
+	long, short := "abcd", "ab"
+	tests := []struct {
+		name string
+		rec  record
+		want []string
+	}{
+		{"valid", record{Name: "Ada", Email: "ada@example.com", Age: 30, Role: "admin", RoomID: 1, Note: &short}, nil},
+		{"only required fields", record{Name: "Ada"}, nil},
+		{"readonly fields are ignored", record{ID: 7, Name: "Ada"}, nil},
+		{"missing required field", record{}, []string{"name:required"}},
+		{"string too short", record{Name: "A"}, []string{"name:min"}},
+		{"string too long", record{Name: "Adelaide"}, []string{"name:max"}},
+		{"length counts characters", record{Name: "Zoëëë"}, nil},
+		{"invalid e-mail", record{Name: "Ada", Email: "ada"}, []string{"email:email"}},
+		{"e-mail with a display name", record{Name: "Ada", Email: "Ada <ada@example.com>"}, []string{"email:email"}},
+		{"number too small", record{Name: "Ada", Age: 17}, []string{"age:min"}},
+		{"number too large", record{Name: "Ada", Age: 100}, []string{"age:max"}},
+		{"not one of", record{Name: "Ada", Role: "root"}, []string{"role:oneof"}},
+		{"missing row", record{Name: "Ada", RoomID: 2}, []string{"room_id:exists"}},
+		{"rules apply through pointers", record{Name: "Ada", Note: &long}, []string{"note:max"}},
+		{
+			"every failing field",
+			record{Email: "x", Age: 1, Role: "root", RoomID: 2},
+			[]string{"name:required", "email:email", "age:min", "role:oneof", "room_id:exists"},
+		},
+	}
+	v := Validator{Exists: exists}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := v.Struct(context.Background(), &tt.rec)
+			if err != nil {
+				t.Fatalf("Struct() error = %v", err)
+			}
+			if got := failures(errs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructExists(t *testing.T) {
+	// Without an Exists function the rule is skipped
+	if errs, err := (Validator{}).Struct(context.Background(), record{Name: "Ada", RoomID: 2}); err != nil || errs != nil {
+		t.Errorf("Struct() without Exists = %v, %v, want no errors", errs, err)
+	}
+	// A failed lookup is an error, not a field failure
+	v := Validator{Exists: exists}
+	if _, err := v.Struct(context.Background(), record{Name: "Ada", RoomID: 500}); !errors.Is(err, errLookup) {
+		t.Errorf("Struct() error = %v, want %v", err, errLookup)
+	}
+	if _, err := v.Struct(context.Background(), "not a struct"); err == nil {
+		t.Error("Struct() of a string succeeded")
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]any // decoded JSON
+		want   []string       // failures, sorted by field
+		bound  map[string]any // values after a successful validation
+	}{
+		{
+			name:   "valid values are converted",
+			values: map[string]any{"name": "Ada", "age": float64(30), "room_id": float64(1), "note": "ab"},
+			bound:  map[string]any{"name": "Ada", "age": 30, "room_id": 1, "note": ptr("ab")},
+		},
+		{
+			name:   "null on an optional field",
+			values: map[string]any{"note": nil, "email": nil},
+			bound:  map[string]any{"note": nil, "email": nil},
+		},
+		{
+			name:   "empty values skip the rules of optional fields",
+			values: map[string]any{"email": "", "age": float64(0)},
+			bound:  map[string]any{"email": "", "age": 0},
+		},
+		{name: "null on a required field", values: map[string]any{"name": nil}, want: []string{"name:required"}},
+		{name: "empty required field", values: map[string]any{"name": ""}, want: []string{"name:required"}},
+		{name: "readonly field", values: map[string]any{"id": float64(3)}, want: []string{"id:readonly"}},
+		{name: "unknown field", values: map[string]any{"grade": "A"}, want: []string{"grade:unknown"}},
+		{name: "ignored field is unknown", values: map[string]any{"Secret": "x"}, want: []string{"Secret:unknown"}},
+		{name: "unexported field is unknown", values: map[string]any{"private": "x"}, want: []string{"private:unknown"}},
+		{name: "string for a number", values: map[string]any{"age": "30"}, want: []string{"age:type"}},
+		{name: "fraction for an integer", values: map[string]any{"age": 30.5}, want: []string{"age:type"}},
+		{name: "number for a string", values: map[string]any{"name": float64(1)}, want: []string{"name:type"}},
+		{name: "missing row", values: map[string]any{"room_id": float64(2)}, want: []string{"room_id:exists"}},
+		{
+			name:   "failures are sorted by field",
+			values: map[string]any{"role": "root", "id": float64(1), "age": float64(5), "name": "A"},
+			want:   []string{"age:min", "id:readonly", "name:min", "role:oneof"},
+		},
+	}
+	v := Validator{Exists: exists}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := v.Fields(context.Background(), record{}, tt.values)
+			if err != nil {
+				t.Fatalf("Fields() error = %v", err)
+			}
+			if got := failures(errs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields() = %v, want %v", got, tt.want)
+			}
+			if tt.want == nil && !reflect.DeepEqual(tt.values, tt.bound) {
+				t.Errorf("values = %#v, want %#v", tt.values, tt.bound)
+			}
+		})
+	}
+
+	values := map[string]any{"room_id": float64(500)}
+	if _, err := v.Fields(context.Background(), record{}, values); !errors.Is(err, errLookup) {
+		t.Errorf("Fields() error = %v, want %v", err, errLookup)
+	}
+}
+
+func TestWritableFields(t *testing.T) {
+	want := []string{"name", "email", "age", "role", "room_id", "note"}
+	for _, model := range []any{record{}, &record{}} {
+		if got := WritableFields(model); !reflect.DeepEqual(got, want) {
+			t.Errorf("WritableFields(%T) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}